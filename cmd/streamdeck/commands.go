@@ -0,0 +1,20 @@
+package main
+
+import "fmt"
+
+// commands contains the subcommands given as first argument which are
+// run instead of driving the decks
+var commands = map[string]func() error{
+	"render":   renderCommand,
+	"schema":   schemaCommand,
+	"validate": validateCommand,
+}
+
+func runCommand(name string) error {
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	return cmd()
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules/opts"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/state"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Luzifer/streamdeck/v2"
+)
+
+const (
+	deckEventBuffer   = 100
+	reconnectInterval = time.Second
+)
+
+type (
+	// deckState holds the connection, configuration and page state of
+	// one connected StreamDeck. The client is replaced when the deck is
+	// reconnected, so modules access it through the deckState which
+	// implements opts.Deck.
+	deckState struct {
+		client     *streamdeck.Client
+		clientLock sync.RWMutex
+		brightness int
+		conf       config.File
+		serial     string
+
+		activePage          config.Page
+		activePageCtx       context.Context
+		activePageCtxCancel context.CancelFunc
+		activePageName      string
+		pageStack           []string
+
+		// held contains the keys currently held down, chordTriggered
+		// whether a chord was triggered since no key was held
+		held           map[int]bool
+		chordTriggered bool
+
+		offTimer *time.Timer
+	}
+
+	// deckEvent is an event received from one of the decks
+	deckEvent struct {
+		deck *deckState
+		evt  streamdeck.Event
+	}
+
+	// deckReconnect carries the new client of a deck which was lost
+	// and appeared again
+	deckReconnect struct {
+		deck   *deckState
+		client *streamdeck.Client
+	}
+)
+
+var (
+	// decks contains all connected decks by their serial
+	decks = make(map[string]*deckState)
+
+	deckEvents      = make(chan deckEvent, deckEventBuffer)
+	deckLost        = make(chan *deckState)
+	deckOff         = make(chan *deckState, 1)
+	deckReconnected = make(chan deckReconnect)
+)
+
+var _ opts.Deck = (*deckState)(nil)
+
+func openDecks() error {
+	infos, err := streamdeck.Enumerate()
+	if err != nil {
+		return fmt.Errorf("getting available decks: %w", err)
+	}
+
+	pid, err := selectedProductID()
+	if err != nil {
+		return fmt.Errorf("parsing given product ID: %w", err)
+	}
+
+	selection := userConfig
+	if len(cfg.Serial) > 0 {
+		selection.Serials = cfg.Serial
+	}
+
+	for _, info := range infos {
+		logger := logrus.WithFields(logrus.Fields{
+			"model":  streamdeck.DeckToName[info.Model],
+			"path":   info.Path,
+			"serial": info.Serial,
+		})
+
+		if (pid != 0 && info.Model != pid) || !selection.UsesDeck(info.Serial) {
+			logger.Debug("Skipping StreamDeck not selected for use")
+			continue
+		}
+
+		d, err := openDeck(info)
+		if err != nil {
+			return fmt.Errorf("opening deck %s: %w", info.Serial, err)
+		}
+
+		firmware, err := d.client.GetFimwareVersion()
+		if err != nil {
+			return fmt.Errorf("reading firmware of deck %s: %w", info.Serial, err)
+		}
+
+		logger.WithField("firmware", firmware).Info("Found StreamDeck")
+		decks[d.serial] = d
+	}
+
+	if len(decks) == 0 {
+		return fmt.Errorf("found no supported decks")
+	}
+
+	return nil
+}
+
+func openDeck(info streamdeck.DeviceInfo) (*deckState, error) {
+	client, err := streamdeck.OpenPath(info.Path)
+	if err != nil {
+		return nil, fmt.Errorf("opening device: %w", err)
+	}
+
+	return newDeckState(client, info.Serial), nil
+}
+
+// newDeckState creates the state for the opened client and starts to
+// forward its events
+func newDeckState(client *streamdeck.Client, serial string) *deckState {
+	d := &deckState{
+		client: client,
+		conf:   userConfig.ForDeck(serial, client),
+		serial: serial,
+		held:   make(map[int]bool),
+	}
+
+	go d.forwardEvents(client)
+
+	return d
+}
+
+func (d *deckState) close() {
+	if d.activePageCtxCancel != nil {
+		d.activePageCtxCancel()
+	}
+
+	if d.client.Err() == nil && d.client.HasDisplay() {
+		if err := d.client.ResetToLogo(); err != nil {
+			d.logger().WithError(err).Error("resetting to logo")
+		}
+	}
+
+	if err := d.client.Close(); err != nil {
+		d.logger().WithError(err).Error("closing deck")
+	}
+}
+
+// FillColor fills a key of the current client with a solid color
+func (d *deckState) FillColor(keyIdx int, col color.RGBA) error {
+	return d.currentClient().FillColor(keyIdx, col) //nolint:wrapcheck // wraps client
+}
+
+// FillImage fills a key of the current client with an image
+func (d *deckState) FillImage(keyIdx int, img image.Image) error {
+	return d.currentClient().FillImage(keyIdx, img) //nolint:wrapcheck // wraps client
+}
+
+// IconSize returns the icon size of the current client
+func (d *deckState) IconSize() int { return d.currentClient().IconSize() }
+
+// SetBrightness sets the brightness of the current client and keeps
+// it to be restored after a reconnect. The brightness is published in
+// the state store for actions and displays to pick it up.
+func (d *deckState) SetBrightness(pct int) error {
+	if err := d.currentClient().SetBrightness(pct); err != nil {
+		return err //nolint:wrapcheck // wraps client
+	}
+
+	if err := stateStore.Set(state.BrightnessKey(d.serial), strconv.Itoa(pct)); err != nil {
+		d.logger().WithError(err).Error("Unable to store brightness")
+	}
+
+	d.clientLock.Lock()
+	defer d.clientLock.Unlock()
+
+	d.brightness = pct
+	return nil
+}
+
+func (d *deckState) currentClient() *streamdeck.Client {
+	d.clientLock.RLock()
+	defer d.clientLock.RUnlock()
+
+	return d.client
+}
+
+// forwardEvents passes the events of the client into the shared event
+// channel and reports the deck lost as soon as the device is gone
+func (d *deckState) forwardEvents(client *streamdeck.Client) {
+	for evt := range client.Subscribe(context.Background()) {
+		deckEvents <- deckEvent{deck: d, evt: evt}
+	}
+
+	if client.Err() != nil {
+		deckLost <- d
+	}
+}
+
+func (d *deckState) handleEvent(evt streamdeck.Event) {
+	d.resetOffTimer()
+
+	switch evt.Type {
+	case streamdeck.EventTypeDown:
+		d.held[evt.Key] = true
+		d.triggerChord()
+		return
+
+	case streamdeck.EventTypeDialDown, streamdeck.EventTypeDialUp, streamdeck.EventTypeDialRotate:
+		if err := d.handleDialEvent(evt); err != nil {
+			d.logger().WithError(err).Error("Unable to execute dial action")
+		}
+		return
+
+	case streamdeck.EventTypeTouchTap, streamdeck.EventTypeTouchLongPress, streamdeck.EventTypeTouchSwipe:
+		if err := d.handleTouchEvent(evt); err != nil {
+			d.logger().WithError(err).Error("Unable to execute touch strip action")
+		}
+		return
+	}
+
+	if !d.held[evt.Key] {
+		// Key was pressed before we started listening
+		return
+	}
+
+	delete(d.held, evt.Key)
+	partOfChord := d.chordTriggered
+	if len(d.held) == 0 {
+		d.chordTriggered = false
+	}
+
+	if partOfChord {
+		// Releasing keys of a chord does not trigger their own actions
+		return
+	}
+
+	kd, ok := d.activePage.GetKeyDefinitions(d.conf)[evt.Key]
+	if !ok {
+		return
+	}
+
+	isLongPress := evt.Duration > d.conf.LongPressDuration
+
+	if err := d.triggerActions(evt.Key, d.keyForState(d.activePageName, evt.Key, kd).Actions, isLongPress); err != nil {
+		d.logger().WithError(err).Error("Unable to execute action")
+		return
+	}
+
+	if isLongPress {
+		// Long presses execute actions of the state without leaving it
+		return
+	}
+
+	if err := d.advanceKeyState(evt.Key, kd); err != nil {
+		d.logger().WithError(err).Error("Unable to advance key state")
+	}
+}
+
+// triggerChord executes the chord matching the currently held keys
+// unless a chord was already triggered while holding keys
+func (d *deckState) triggerChord() {
+	if d.chordTriggered {
+		return
+	}
+
+	held := slices.Sorted(maps.Keys(d.held))
+	for _, c := range d.activePage.GetChords(d.conf) {
+		if !c.Matches(held) {
+			continue
+		}
+
+		d.chordTriggered = true
+		d.logger().WithField("keys", held).Debug("Chord triggered")
+
+		if err := d.triggerActions(-1, c.Actions, false); err != nil {
+			d.logger().WithError(err).Error("Unable to execute chord action")
+		}
+
+		return
+	}
+}
+
+func (d *deckState) logger() *logrus.Entry {
+	return logrus.WithField("serial", d.serial)
+}
+
+// handleLoss stops rendering onto the lost deck and starts waiting for
+// it to be connected again
+func (d *deckState) handleLoss() {
+	d.logger().WithError(d.client.Err()).Warn("Lost connection to StreamDeck, waiting for reconnect")
+
+	if d.activePageCtxCancel != nil {
+		d.activePageCtxCancel()
+	}
+
+	if d.offTimer != nil {
+		d.offTimer.Stop()
+	}
+
+	go d.waitForReconnect()
+}
+
+// reconnect replaces the lost client and restores brightness and the
+// active page including its display loops
+func (d *deckState) reconnect(client *streamdeck.Client) {
+	d.clientLock.Lock()
+	lost := d.client
+	d.client = client
+	brightness := d.brightness
+	d.clientLock.Unlock()
+
+	// The lost client still holds the handle of the removed device
+	if err := lost.Close(); err != nil {
+		d.logger().WithError(err).Debug("Unable to close lost StreamDeck")
+	}
+
+	// Keys held while the deck was lost will never be released
+	d.held = make(map[int]bool)
+	d.chordTriggered = false
+
+	go d.forwardEvents(client)
+
+	d.logger().Info("StreamDeck reconnected")
+
+	if client.HasDisplay() {
+		if err := client.SetBrightness(brightness); err != nil {
+			d.logger().WithError(err).Error("Unable to restore brightness")
+		}
+	}
+
+	if err := d.togglePage(d.activePageName); err != nil {
+		d.logger().WithError(err).Error("Unable to restore active page")
+	}
+
+	d.resetOffTimer()
+}
+
+func (d *deckState) resetOffTimer() {
+	// Decks without display have nothing to switch off, the blank page
+	// would swallow the next key press
+	if d.conf.DisplayOffTime <= 0 || !d.client.HasDisplay() {
+		if d.offTimer != nil {
+			d.offTimer.Stop()
+		}
+		return
+	}
+
+	if d.offTimer == nil {
+		d.offTimer = time.AfterFunc(d.conf.DisplayOffTime, func() { deckOff <- d })
+		return
+	}
+
+	d.offTimer.Reset(d.conf.DisplayOffTime)
+}
+
+func (d *deckState) setup() error {
+	if d.client.HasDisplay() {
+		if err := d.SetBrightness(d.conf.DefaultBrightness); err != nil {
+			return fmt.Errorf("setting brightness: %w", err)
+		}
+	}
+
+	if err := d.togglePage(d.conf.DefaultPage); err != nil {
+		d.logger().WithError(err).Error("Unable to load default page")
+	}
+
+	d.resetOffTimer()
+
+	return nil
+}
+
+// waitForReconnect polls for the deck to appear again and hands the
+// new client to the main loop
+func (d *deckState) waitForReconnect() {
+	for {
+		time.Sleep(reconnectInterval)
+
+		client, err := streamdeck.Open(d.serial)
+		if err != nil {
+			if !errors.Is(err, streamdeck.ErrNotFound) {
+				d.logger().WithError(err).Debug("Unable to reopen StreamDeck")
+			}
+			continue
+		}
+
+		deckReconnected <- deckReconnect{deck: d, client: client}
+		return
+	}
+}
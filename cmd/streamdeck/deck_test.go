@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Luzifer/streamdeck/v2"
+)
+
+// newTestDeck creates a deck of the model with the configuration
+// without forwarding events of the client, events are passed to
+// handleEvent by the test
+func newTestDeck(t *testing.T, model uint16, conf string) (*deckState, *streamdeck.FakeDeck) {
+	t.Helper()
+
+	fake, err := streamdeck.NewFakeDeck(model)
+	require.NoError(t, err)
+
+	client, err := streamdeck.NewWithTransport(model, fake)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	filename := path.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte(conf), 0o600))
+
+	f, err := config.Load(filename)
+	require.NoError(t, err)
+
+	// The state store is shared by all tests, keep key states apart
+	serial := fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano())
+
+	d := &deckState{
+		client: client,
+		conf:   f.ForDeck(serial, client),
+		serial: serial,
+		held:   make(map[int]bool),
+	}
+	t.Cleanup(func() {
+		if d.activePageCtxCancel != nil {
+			d.activePageCtxCancel()
+		}
+	})
+
+	return d, fake
+}
+
+// pressKey passes a short press of the key to the deck
+func pressKey(d *deckState, idx int) {
+	d.handleEvent(streamdeck.Event{Type: streamdeck.EventTypeDown, Key: idx})
+	d.handleEvent(streamdeck.Event{Type: streamdeck.EventTypeUp, Key: idx})
+}
+
+func TestPedalKeysTriggerActions(t *testing.T) {
+	t.Parallel()
+
+	d, _ := newTestDeck(t, streamdeck.StreamDeckPedal, `---
+default_page: main
+pages:
+  main:
+    keys:
+      1:
+        actions:
+          - type: state
+            attributes:
+              key: TestPedalKeysTriggerActions
+              value: pressed
+`)
+	require.NoError(t, d.togglePage("main"))
+
+	pressKey(d, 1)
+
+	v, _ := stateStore.Get("TestPedalKeysTriggerActions")
+	assert.Equal(t, "pressed", v)
+
+	// Display-less decks return from the blank page on any key
+	require.NoError(t, d.togglePage("@@blank"))
+	pressKey(d, 0)
+	assert.Equal(t, "main", d.activePageName)
+}
+
+func TestMultiStateKeyDisplayFromStates(t *testing.T) {
+	t.Parallel()
+
+	d, fake := newTestDeck(t, streamdeck.StreamDeckMini, `---
+default_page: main
+pages:
+  main:
+    keys:
+      0:
+        states:
+          - name: "off"
+            display:
+              type: color
+              attributes:
+                rgba: [255, 0, 0, 255]
+            actions:
+              - type: state
+                attributes:
+                  key: TestMultiStateKeyDisplayFromStates
+                  value: turned on
+          - name: "on"
+            display:
+              type: color
+              attributes:
+                rgba: [0, 255, 0, 255]
+`)
+	require.NoError(t, d.togglePage("main"))
+
+	keyColor := func() color.RGBA {
+		img := fake.KeyImage(0)
+		if img == nil {
+			return color.RGBA{}
+		}
+		return color.RGBAModel.Convert(img.At(img.Bounds().Min.X, img.Bounds().Min.Y)).(color.RGBA) //nolint:forcetypeassert // converted by model
+	}
+
+	assert.Eventually(t, func() bool { return keyColor() == color.RGBA{0xff, 0x0, 0x0, 0xff} }, time.Second, 5*time.Millisecond)
+
+	pressKey(d, 0)
+
+	v, _ := stateStore.Get("TestMultiStateKeyDisplayFromStates")
+	assert.Equal(t, "turned on", v)
+
+	v, _ = stateStore.Get(d.keyStateKey("main", 0, config.KeyDefinition{}))
+	assert.Equal(t, "on", v)
+
+	assert.Eventually(t, func() bool { return keyColor() == color.RGBA{0x0, 0xff, 0x0, 0xff} }, time.Second, 5*time.Millisecond)
+}
+
+func TestReconnectClosesLostClient(t *testing.T) {
+	t.Parallel()
+
+	d, lost := newTestDeck(t, streamdeck.StreamDeckMini, `---
+default_page: main
+pages:
+  main:
+    keys: {}
+`)
+	require.NoError(t, d.togglePage("main"))
+
+	fake, err := streamdeck.NewFakeDeck(streamdeck.StreamDeckMini)
+	require.NoError(t, err)
+
+	client, err := streamdeck.NewWithTransport(streamdeck.StreamDeckMini, fake)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	d.reconnect(client)
+
+	assert.Equal(t, client, d.client)
+
+	_, err = lost.Write([]byte{0x0})
+	assert.Error(t, err, "transport of lost client not closed")
+
+	_, err = fake.Write([]byte{0x0})
+	assert.NoError(t, err)
+}
+
+func TestSyncKeyStateOfStartedPage(t *testing.T) {
+	t.Parallel()
+
+	d, _ := newTestDeck(t, streamdeck.StreamDeckMini, `---
+default_page: first
+pages:
+  first:
+    keys:
+      0:
+        states:
+          - name: "off"
+          - name: "on"
+        state_command:
+          command: [echo, "on"]
+  second:
+    keys: {}
+`)
+	kd := d.conf.Pages["first"].Keys[0]
+
+	// The page was switched before the state command of the first page
+	// finished, its state must not be stored for the active page
+	require.NoError(t, d.togglePage("second"))
+	d.syncKeyState(t.Context(), "first", 0, kd)
+
+	v, _ := stateStore.Get(d.keyStateKey("first", 0, kd))
+	assert.Equal(t, "on", v)
+
+	_, ok := stateStore.Get(d.keyStateKey("second", 0, kd))
+	assert.False(t, ok)
+}
+
+func TestPedalKeepsPageWithDisplayOffTime(t *testing.T) {
+	t.Parallel()
+
+	d, _ := newTestDeck(t, streamdeck.StreamDeckPedal, `---
+default_page: main
+display_off_time: 1ms
+pages:
+  main:
+    keys: {}
+`)
+	require.NoError(t, d.togglePage("main"))
+	require.Positive(t, d.conf.DisplayOffTime)
+
+	d.resetOffTimer()
+	assert.Nil(t, d.offTimer)
+}
+
+func TestPanelLoadDoesNotBlockPageSwitch(t *testing.T) {
+	// Downloads are cached in the user cache dir
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+
+		img := image.NewRGBA(image.Rect(0, 0, 240, 160))
+		draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{0xff, 0x0, 0x0, 0xff}), image.Point{}, draw.Src)
+		_ = png.Encode(w, img)
+	}))
+	t.Cleanup(srv.Close)
+
+	d, fake := newTestDeck(t, streamdeck.StreamDeckMini, fmt.Sprintf(`---
+default_page: main
+pages:
+  main:
+    panel:
+      url: %q
+    keys: {}
+`, srv.URL))
+
+	switched := make(chan error, 1)
+	go func() { switched <- d.togglePage("main") }()
+
+	select {
+	case err := <-switched:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		close(release)
+		t.Fatal("page switch waited for the wallpaper download")
+	}
+
+	close(release)
+
+	assert.Eventually(t, func() bool {
+		img := fake.KeyImage(0)
+		return img != nil && color.RGBAModel.Convert(img.At(img.Bounds().Min.X, img.Bounds().Min.Y)) == color.RGBA{0xff, 0x0, 0x0, 0xff}
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestDialDisplayFillsRegion(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{0xff, 0x0, 0x0, 0xff}), image.Point{}, draw.Src)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, png.Encode(buf, img))
+
+	filename := path.Join(t.TempDir(), "dial.png")
+	require.NoError(t, os.WriteFile(filename, buf.Bytes(), 0o600))
+
+	d, fake := newTestDeck(t, streamdeck.StreamDeckPlus, fmt.Sprintf(`---
+default_page: main
+pages:
+  main:
+    dials:
+      0:
+        display:
+          type: image
+          attributes:
+            path: %q
+`, filename))
+	require.NoError(t, d.togglePage("main"))
+
+	// The image must not be letterboxed into a square of the region
+	// height: the left edge of the dial region shows the image
+	region := d.client.DialRegion(0)
+	require.Equal(t, image.Pt(200, 100), region.Size())
+
+	assert.Eventually(t, func() bool {
+		lcd := fake.LCDImage()
+		if lcd == nil {
+			return false
+		}
+
+		r, g, _, _ := lcd.At(region.Min.X+5, region.Min.Y+region.Dy()/2).RGBA()
+		return r > 0xc000 && g < 0x4000
+	}, time.Second, 5*time.Millisecond)
+}
@@ -1,14 +1,40 @@
 package main
 
-import "github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules/opts"
+import (
+	"fmt"
 
-func moduleRuntime() opts.Runtime {
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules/opts"
+
+	"github.com/Luzifer/streamdeck/v2"
+)
+
+func deckRuntime(serial string) (opts.Runtime, error) {
+	d, ok := decks[serial]
+	if !ok {
+		return opts.Runtime{}, fmt.Errorf("deck %q is not connected", serial)
+	}
+
+	return d.moduleRuntime(d.activePageName), nil
+}
+
+// moduleRuntime returns the runtime for modules executed on the page,
+// renderers running in the background pass the page they were started
+// for as the active page might have been switched meanwhile
+func (d *deckState) moduleRuntime(page string) opts.Runtime {
 	return opts.Runtime{
-		Conf:               userConfig,
-		Deck:               sd,
-		Keyboard:           kbd,
+		Conf:     d.conf,
+		Deck:     d,
+		Keyboard: kbd,
+
+		Key:    -1,
+		Model:  streamdeck.DeckToName[d.currentClient().Model()],
+		Page:   page,
+		Serial: d.serial,
+		State:  stateStore,
+
+		DeckRuntime:        deckRuntime,
 		ReloadConfig:       reloadConfig,
-		TogglePage:         togglePage,
-		ToggleRelativePage: toggleRelativePage,
+		TogglePage:         d.togglePage,
+		ToggleRelativePage: d.toggleRelativePage,
 	}
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Luzifer/streamdeck/v2"
+)
+
+func (d *deckState) handleDialEvent(evt streamdeck.Event) error {
+	dd, ok := d.activePage.GetDialDefinitions(d.conf)[evt.Dial]
+
+	switch evt.Type {
+	case streamdeck.EventTypeDialUp:
+		// Without a duration the dial was pressed before we started
+		// listening and the press is not complete
+		if !ok || evt.Duration == 0 {
+			return nil
+		}
+
+		return d.triggerActions(evt.Dial, dd.Actions, evt.Duration > d.conf.LongPressDuration)
+
+	case streamdeck.EventTypeDialRotate:
+		if !ok {
+			return nil
+		}
+
+		actions, ticks := dd.RotateRight, evt.Delta
+		if evt.Delta < 0 {
+			actions, ticks = dd.RotateLeft, -evt.Delta
+		}
+
+		// Execute the actions once per tick the dial was rotated
+		for range ticks {
+			if err := d.triggerActions(evt.Dial, actions, false); err != nil {
+				return fmt.Errorf("executing rotate action: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (d *deckState) handleTouchEvent(evt streamdeck.Event) error {
+	ts := d.activePage.GetTouchStrip(d.conf)
+
+	switch evt.Type {
+	case streamdeck.EventTypeTouchTap:
+		return d.triggerActions(-1, ts.Actions, false)
+
+	case streamdeck.EventTypeTouchLongPress:
+		return d.triggerActions(-1, ts.Actions, true)
+
+	case streamdeck.EventTypeTouchSwipe:
+		if evt.End.X < evt.Point.X {
+			return d.triggerActions(-1, ts.SwipeLeft, false)
+		}
+
+		return d.triggerActions(-1, ts.SwipeRight, false)
+	}
+
+	return nil
+}
@@ -5,59 +5,62 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/sstallion/go-hid"
+	"github.com/sirupsen/logrus"
 
 	"github.com/Luzifer/streamdeck/v2"
 )
 
-func getAvailableDecks() (out []uint16, err error) {
-	if err = hid.Enumerate(streamdeck.VendorElgato, hid.ProductIDAny, func(info *hid.DeviceInfo) error {
-		if _, ok := streamdeck.DeckToName[info.ProductID]; !ok {
-			// Is from Elgato but not a supported StreamDeck
-			return nil
-		}
-
-		out = append(out, info.ProductID)
-		return nil
-	}); err != nil {
-		return nil, fmt.Errorf("enumerating devices: %w", err)
-	}
-
-	return out, nil
-}
-
 func listDevices() error {
-	av, err := getAvailableDecks()
+	av, err := streamdeck.Enumerate()
 	if err != nil {
 		return fmt.Errorf("getting available decks: %w", err)
 	}
 
-	for _, id := range av {
-		fmt.Printf("0x%x - %s\n", id, streamdeck.DeckToName[id]) //nolint:forbidigo // printing explicitly requested
+	for _, info := range av {
+		firmware, err := info.FirmwareVersion()
+		if err != nil {
+			logrus.WithError(err).WithField("path", info.Path).Error("reading firmware version")
+			firmware = "unknown"
+		}
+
+		//nolint:forbidigo // printing explicitly requested
+		fmt.Printf("0x%04x - %s - serial %s - firmware %s - path %s\n",
+			info.Model, streamdeck.DeckToName[info.Model], info.Serial, firmware, info.Path)
 	}
 
 	return nil
 }
 
-func selectDeckToUse() (uint16, error) {
-	if cfg.ProductID != "" {
-		// User selected a specific deck to use
-		id, err := strconv.ParseUint(strings.TrimPrefix(cfg.ProductID, "0x"), 16, 16)
-		if err != nil {
-			return 0, fmt.Errorf("parsing given product ID: %w", err)
-		}
-		return uint16(id), nil
+// selectedProductID returns the product ID the user restricted the
+// decks to use to or zero if all decks should be used
+func selectedProductID() (uint16, error) {
+	if cfg.ProductID == "" {
+		return 0, nil
 	}
 
-	av, err := getAvailableDecks()
+	id, err := strconv.ParseUint(strings.TrimPrefix(cfg.ProductID, "0x"), 16, 16)
 	if err != nil {
-		return 0, fmt.Errorf("getting available decks: %w", err)
+		return 0, fmt.Errorf("parsing product ID: %w", err)
+	}
+
+	return uint16(id), nil
+}
+
+// parseModel resolves the model given by its product ID or its
+// name with or without the "StreamDeck" prefix (i.e. "xl", "Mini V2"
+// or "0x006c")
+func parseModel(model string) (uint16, error) {
+	normalize := func(name string) string {
+		name = strings.ToLower(name)
+		name = strings.TrimPrefix(name, "streamdeck")
+		return strings.NewReplacer(" ", "", "-", "", "_", "", ".", "").Replace(name)
 	}
 
-	if len(av) == 0 {
-		return 0, fmt.Errorf("found no supported decks")
+	for pid, name := range streamdeck.DeckToName {
+		if normalize(name) == normalize(model) || fmt.Sprintf("0x%04x", pid) == strings.ToLower(model) {
+			return pid, nil
+		}
 	}
 
-	// There is at least one supported deck, use the first one
-	return av[0], nil
+	return 0, fmt.Errorf("unknown model %q", model)
 }
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+// keyStateKey returns the name of the value holding the state of the
+// multi-state key on the page
+func (d *deckState) keyStateKey(page string, idx int, kd config.KeyDefinition) string {
+	if kd.StateKey != "" {
+		return kd.StateKey
+	}
+
+	return fmt.Sprintf("key_state.%s.%s.%d", d.serial, page, idx)
+}
+
+// keyForState returns the key as shown in its current state, keys
+// without states are returned unchanged
+func (d *deckState) keyForState(page string, idx int, kd config.KeyDefinition) config.KeyDefinition {
+	if len(kd.States) == 0 {
+		return kd
+	}
+
+	current, _ := stateStore.Get(d.keyStateKey(page, idx, kd))
+	return kd.ForState(current)
+}
+
+// advanceKeyState switches the multi-state key to its next state
+func (d *deckState) advanceKeyState(idx int, kd config.KeyDefinition) error {
+	if len(kd.States) == 0 {
+		return nil
+	}
+
+	key := d.keyStateKey(d.activePageName, idx, kd)
+	current, _ := stateStore.Get(key)
+
+	if err := stateStore.Set(key, kd.NextState(current)); err != nil {
+		return fmt.Errorf("storing key state: %w", err)
+	}
+
+	return nil
+}
+
+// renderKeyStates renders the display of the current state of the
+// multi-state key and renders it again whenever the state changes
+// until the context is cancelled
+func (d *deckState) renderKeyStates(ctx context.Context, page string, idx int, kd config.KeyDefinition, background func() image.Image) {
+	changes := stateStore.Watch(ctx, d.keyStateKey(page, idx, kd))
+
+	for {
+		// Displays of the previous state might still be refreshing
+		stateCtx, cancel := context.WithCancel(ctx)
+
+		if skd := d.keyForState(page, idx, kd); skd.Display.Type != "" {
+			d.renderDisplay(stateCtx, page, idx, d, skd, "key", background)
+		} else if err := d.clearKey(idx, background); err != nil {
+			d.logger().WithError(err).WithField("key", idx).Error("Unable to clear key")
+		}
+
+		select {
+		case <-ctx.Done():
+			cancel()
+			return
+
+		case <-changes:
+			cancel()
+		}
+	}
+}
+
+// clearKey shows the wallpaper tile on the key or clears it if there
+// is no wallpaper
+func (d *deckState) clearKey(idx int, background func() image.Image) error {
+	if tile := background(); tile != nil {
+		return d.FillImage(idx, tile)
+	}
+
+	return d.currentClient().ClearKey(idx) //nolint:wrapcheck // wraps client
+}
+
+// syncKeyState executes the state command of the multi-state key when
+// showing the page and at its interval and switches the key into the
+// state named by the output of the command until the context is
+// cancelled
+func (d *deckState) syncKeyState(ctx context.Context, page string, idx int, kd config.KeyDefinition) {
+	var (
+		key    = d.keyStateKey(page, idx, kd)
+		logger = d.logger().WithFields(logrus.Fields{"key": idx, "page": page})
+	)
+
+	for {
+		name, err := runStateCommand(ctx, kd.StateCommand)
+		switch {
+		case ctx.Err() != nil:
+			return
+
+		case err != nil:
+			logger.WithError(err).Error("Unable to execute state command")
+
+		default:
+			if _, ok := kd.StateIndex(name); !ok {
+				logger.WithField("state", name).Warn("State command returned unknown state")
+				break
+			}
+
+			if err = stateStore.Set(key, name); err != nil {
+				logger.WithError(err).Error("Unable to store key state")
+			}
+		}
+
+		if kd.StateCommand.Interval <= 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-time.After(kd.StateCommand.Interval):
+		}
+	}
+}
+
+// runStateCommand executes the state command and returns its output,
+// the command is killed when it does not finish within its interval
+func runStateCommand(ctx context.Context, sc config.StateCommand) (string, error) {
+	if sc.Interval > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sc.Interval)
+		defer cancel()
+	}
+
+	buf := new(bytes.Buffer)
+
+	command := exec.CommandContext(ctx, sc.Command[0], sc.Command[1:]...) //#nosec:G204 // intended to run user-defined command
+	command.Stdout = buf
+
+	if err := command.Run(); err != nil {
+		return "", fmt.Errorf("running command: %w", err)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
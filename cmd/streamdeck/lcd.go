@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules/opts"
+)
+
+// lcdRegionDeck renders display elements into a region of the LCD
+// strip instead of a key
+type lcdRegionDeck struct {
+	deck   *deckState
+	region image.Rectangle
+}
+
+var _ opts.SizedDeck = lcdRegionDeck{}
+
+func (l lcdRegionDeck) FillColor(_ int, col color.RGBA) error {
+	img := image.NewRGBA(image.Rectangle{Max: l.region.Size()})
+	draw.Draw(img, img.Bounds(), image.NewUniform(col), image.Point{}, draw.Src)
+
+	return l.FillImage(0, img)
+}
+
+func (l lcdRegionDeck) FillImage(_ int, img image.Image) error {
+	if err := l.deck.currentClient().FillLCDRegion(l.region, img); err != nil {
+		return fmt.Errorf("filling LCD region: %w", err)
+	}
+
+	return nil
+}
+
+func (l lcdRegionDeck) DisplaySize() image.Point { return l.region.Size() }
+
+func (l lcdRegionDeck) IconSize() int { return l.region.Dy() }
+
+func (l lcdRegionDeck) SetBrightness(pct int) error { return l.deck.SetBrightness(pct) } //nolint:wrapcheck // wraps client
+
+func (d *deckState) renderLCD(ctx context.Context, page string) error {
+	lcdSize := d.client.LCDSize()
+	if lcdSize == (image.Point{}) {
+		// Device has no LCD strip
+		return nil
+	}
+
+	if err := (lcdRegionDeck{deck: d, region: image.Rectangle{Max: lcdSize}}).
+		FillColor(0, color.RGBA{0x0, 0x0, 0x0, 0xff}); err != nil {
+		return fmt.Errorf("clearing LCD: %w", err)
+	}
+
+	var hasDialDisplay bool
+	for idx, dd := range d.activePage.GetDialDefinitions(d.conf) {
+		if dd.Display.Type == "" {
+			continue
+		}
+
+		hasDialDisplay = true
+		go d.renderDisplay(ctx, page, idx, lcdRegionDeck{deck: d, region: d.client.DialRegion(idx)}, config.KeyDefinition{Display: dd.Display}, "dial", nil)
+	}
+
+	if ts := d.activePage.GetTouchStrip(d.conf); !hasDialDisplay && ts.Display.Type != "" {
+		go d.renderDisplay(ctx, page, 0, lcdRegionDeck{deck: d, region: image.Rectangle{Max: lcdSize}}, config.KeyDefinition{Display: ts.Display}, "touch_strip", nil)
+	}
+
+	return nil
+}
+
+// infoBarDeck renders display elements onto the info bar instead of
+// a key
+type infoBarDeck struct {
+	deck *deckState
+}
+
+var _ opts.SizedDeck = infoBarDeck{}
+
+func (i infoBarDeck) FillColor(_ int, col color.RGBA) error {
+	img := image.NewRGBA(image.Rectangle{Max: i.deck.currentClient().InfoBarSize()})
+	draw.Draw(img, img.Bounds(), image.NewUniform(col), image.Point{}, draw.Src)
+
+	return i.FillImage(0, img)
+}
+
+func (i infoBarDeck) FillImage(_ int, img image.Image) error {
+	if err := i.deck.currentClient().FillInfoBar(img); err != nil {
+		return fmt.Errorf("filling info bar: %w", err)
+	}
+
+	return nil
+}
+
+func (i infoBarDeck) DisplaySize() image.Point { return i.deck.currentClient().InfoBarSize() }
+
+func (i infoBarDeck) IconSize() int { return i.deck.currentClient().InfoBarSize().Y }
+
+func (i infoBarDeck) SetBrightness(pct int) error { return i.deck.SetBrightness(pct) } //nolint:wrapcheck // wraps client
+
+func (d *deckState) renderInfoBar(ctx context.Context, page string) error {
+	if !d.client.HasInfoBar() {
+		return nil
+	}
+
+	bar := infoBarDeck{deck: d}
+	if err := bar.FillColor(0, color.RGBA{0x0, 0x0, 0x0, 0xff}); err != nil {
+		return fmt.Errorf("clearing info bar: %w", err)
+	}
+
+	if ib := d.activePage.GetInfoBar(d.conf); ib.Type != "" {
+		go d.renderDisplay(ctx, page, 0, bar, config.KeyDefinition{Display: ib}, "info_bar", nil)
+	}
+
+	return nil
+}
@@ -7,38 +7,44 @@ import (
 	"os"
 	"os/signal"
 	"path"
+	"slices"
 	"syscall"
-	"time"
 
 	"github.com/Luzifer/rconfig/v2"
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/secrets"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/state"
 	"github.com/fsnotify/fsnotify"
 	"github.com/sashko/go-uinput"
 	"github.com/sirupsen/logrus"
-
-	"github.com/Luzifer/streamdeck/v2"
 )
 
 const maxPageStackSize = 100
 
 var (
 	cfg = struct {
-		Config         string `flag:"config,c" vardefault:"config" description:"Configuration with page / key definitions"`
-		List           bool   `flag:"list,l" default:"false" description:"List all available StreamDecks"`
-		LogLevel       string `flag:"log-level" default:"info" description:"Log level (debug, info, warn, error, fatal)"`
-		ProductID      string `flag:"product-id,p" default:"" description:"Specify StreamDeck to use (use list to find ID), default first found"`
-		VersionAndExit bool   `flag:"version" default:"false" description:"Prints current version and exits"`
+		Config         string   `flag:"config,c" vardefault:"config" description:"Configuration with page / key definitions"`
+		Gap            int      `flag:"gap" default:"16" description:"Pixels between the keys in the rendered image (render)"`
+		List           bool     `flag:"list,l" default:"false" description:"List all available StreamDecks"`
+		LogLevel       string   `flag:"log-level" default:"info" description:"Log level (debug, info, warn, error, fatal)"`
+		Model          string   `flag:"model" default:"" description:"Model (name or product ID) to render the page for (render) or to check keys and dials against (validate)"`
+		Out            string   `flag:"out,o" default:"page.png" description:"File to write the rendered image to (render)"`
+		Page           string   `flag:"page" default:"" description:"Page to render, defaults to the default page (render)"`
+		ProductID      string   `flag:"product-id,p" default:"" description:"Only use StreamDecks of this product ID (use list to find ID), default all found"`
+		Serial         []string `flag:"serial,s" default:"" description:"Only use StreamDecks with these serials (use list to find serial), overrides serials from config"`
+		Simulate       string   `flag:"simulate" default:"" description:"Simulate a StreamDeck of this model (name or product ID) with a web UI instead of using connected devices, first serial given is used for the simulated deck"`
+		SimulateListen string   `flag:"simulate-listen" default:"localhost:3000" description:"Address to serve the simulator web UI on"`
+		StateFile      string   `flag:"state-file" default:"" description:"File to keep the state values in across restarts, kept in memory only if empty"`
+		VersionAndExit bool     `flag:"version" default:"false" description:"Prints current version and exits"`
+		WarmCache      bool     `flag:"warm-cache" default:"true" description:"Encode the static keys of all pages at startup"`
 	}{}
 
-	userConfig          config.File
-	activePage          config.Page
-	activePageCtx       context.Context
-	activePageCtxCancel context.CancelFunc
-	activePageName      string
-	pageStack           []string
+	userConfig    config.File
+	configWatcher *fsnotify.Watcher
 
-	sd *streamdeck.Client
+	// stateStore contains the variables shared by all decks
+	stateStore = state.New()
 
 	kbd uinput.Keyboard
 
@@ -64,11 +70,11 @@ func initApp() (err error) {
 		return fmt.Errorf("parsing log-level: %w", err)
 	}
 	logrus.SetLevel(l)
+	logrus.AddHook(secrets.LogHook{})
 
 	return nil
 }
 
-//nolint:gocognit,gocyclo,funlen // ignore for now, fix later™
 func main() {
 	var err error
 	if err = initApp(); err != nil {
@@ -80,6 +86,13 @@ func main() {
 		os.Exit(0)
 	}
 
+	if args := rconfig.Args(); len(args) > 1 {
+		if err = runCommand(args[1]); err != nil {
+			logrus.WithError(err).Fatalf("running %s command", args[1])
+		}
+		os.Exit(0)
+	}
+
 	if cfg.List {
 		if err = listDevices(); err != nil {
 			logrus.WithError(err).Fatal("listing devices")
@@ -87,121 +100,90 @@ func main() {
 		os.Exit(0)
 	}
 
-	deck, err := selectDeckToUse()
-	if err != nil {
-		logrus.WithError(err).Fatal("Unable to select StreamDeck to use")
-	}
-
 	// Initialize control devices
 	kbd, err = uinput.CreateKeyboard()
-	if err != nil {
+	switch {
+	case err == nil:
+		defer kbd.Close() //nolint:errcheck // closed either way by process exit
+
+	case cfg.Simulate != "":
+		// Development machines might not allow uinput access
+		logrus.WithError(err).Warn("Unable to create uinput keyboard, key_press actions will fail")
+		kbd = nil
+
+	default:
 		logrus.WithError(err).Fatal("Unable to create uinput keyboard")
 	}
-	defer kbd.Close() //nolint:errcheck // closed either way by process exit
 
-	// Initialize device
-	sd, err = streamdeck.New(deck)
-	if err != nil {
-		logrus.WithError(err).Fatal("Unable to open StreamDeck connection")
+	// Load state persisted before
+	if cfg.StateFile != "" {
+		if stateStore, err = state.Open(cfg.StateFile); err != nil {
+			logrus.WithError(err).Fatal("loading state")
+		}
 	}
-	defer sd.Close() //nolint:errcheck // closed either way by process exit
 
-	serial, err := sd.Serial()
-	if err != nil {
-		logrus.WithError(err).Fatal("Unable to read serial")
+	// Load config
+	if userConfig, err = config.Load(cfg.Config); err != nil {
+		logrus.WithError(err).Fatal("loading config")
 	}
 
-	firmware, err := sd.GetFimwareVersion()
+	// Initialize devices
+	if cfg.Simulate != "" {
+		err = openSimulatedDeck()
+	} else {
+		err = openDecks()
+	}
 	if err != nil {
-		logrus.WithError(err).Fatal("Unable to read firmware")
+		logrus.WithError(err).Fatal("Unable to open StreamDeck connections")
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"firmware": firmware,
-		"serial":   serial,
-	}).Info("Found StreamDeck")
-
-	// Load config
-	if userConfig, err = config.Load(cfg.Config, sd); err != nil {
-		logrus.WithError(err).Fatal("loading config")
-	}
+	defer func() {
+		for _, d := range decks {
+			d.close()
+		}
+	}()
 
 	// Initial setup
 
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
 
-	defer func() {
-		if err := sd.ResetToLogo(); err != nil {
-			logrus.WithError(err).Error("resetting to logo")
+	for _, d := range decks {
+		if err = d.setup(); err != nil {
+			d.logger().WithError(err).Fatal("Unable to set up StreamDeck")
 		}
-	}()
-
-	if err = sd.SetBrightness(userConfig.DefaultBrightness); err != nil {
-		logrus.WithError(err).Fatal("Unable to set brightness")
-	}
-
-	if err = togglePage(userConfig.DefaultPage); err != nil {
-		logrus.WithError(err).Error("Unable to load default page")
-	}
 
-	offTimer := &time.Timer{}
-	if userConfig.DisplayOffTime > 0 {
-		offTimer = time.NewTimer(userConfig.DisplayOffTime)
+		if cfg.WarmCache {
+			go d.warmEncodeCache(context.Background(), d.moduleRuntime(d.activePageName))
+		}
 	}
 
-	fswatch, err := fsnotify.NewWatcher()
-	if err != nil {
+	if configWatcher, err = fsnotify.NewWatcher(); err != nil {
 		logrus.WithError(err).Fatal("Unable to create file watcher")
 	}
+	defer configWatcher.Close() //nolint:errcheck // closed either way by process exit
 
-	if userConfig.AutoReload {
-		if err = fswatch.Add(cfg.Config); err != nil {
-			logrus.WithError(err).Error("Unable to watch config, auto-reload will not work")
-		}
-	}
-
-	var (
-		actor    *int
-		actStart time.Time
-	)
+	watchConfigFiles()
 
 	for {
 		select {
-		case evt := <-sd.Subscribe():
-			if userConfig.DisplayOffTime > 0 {
-				offTimer.Reset(userConfig.DisplayOffTime)
-			}
-
-			if evt.Type == streamdeck.EventTypeDown {
-				actor = &evt.Key
-				actStart = time.Now()
-				continue
-			}
-
-			if evt.Key != *actor {
-				continue
-			}
+		case de := <-deckEvents:
+			de.deck.handleEvent(de.evt)
 
-			kd, ok := activePage.GetKeyDefinitions(userConfig)[*actor]
-			if !ok {
-				continue
-			}
-
-			isLongPress := time.Since(actStart) > userConfig.LongPressDuration
+		case d := <-deckLost:
+			d.handleLoss()
 
-			if err := triggerAction(kd, isLongPress); err != nil {
-				logrus.WithError(err).Error("Unable to execute action")
-			}
+		case r := <-deckReconnected:
+			r.deck.reconnect(r.client)
 
-		case <-offTimer.C:
-			if err := togglePage("@@blank"); err != nil {
-				logrus.WithError(err).Error("Unable to toggle to blank page")
+		case d := <-deckOff:
+			if err := d.togglePage("@@blank"); err != nil {
+				d.logger().WithError(err).Error("Unable to toggle to blank page")
 			}
 
-		case evt := <-fswatch.Events:
+		case evt := <-configWatcher.Events:
 			if evt.Op&fsnotify.Write == fsnotify.Write {
-				logrus.Info("Detected change of config, reloading")
+				logrus.WithField("file", evt.Name).Info("Detected change of config, reloading")
 
 				if err = reloadConfig(); err != nil {
 					logrus.WithError(err).Error("reloading config")
@@ -215,29 +197,70 @@ func main() {
 	}
 }
 
+// reloadConfig reloads the configuration for all connected decks. Decks
+// added to the configuration are not opened until the next start.
 func reloadConfig() (err error) {
 	var tmpConfig config.File
 
-	if tmpConfig, err = config.Load(cfg.Config, sd); err != nil {
+	if tmpConfig, err = config.Load(cfg.Config); err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
 	userConfig = tmpConfig
+	watchConfigFiles()
 
-	nextPage := userConfig.DefaultPage
-	if _, ok := userConfig.Pages[activePageName]; ok {
-		nextPage = activePageName
-	}
+	for _, d := range decks {
+		d.conf = userConfig.ForDeck(d.serial, d.client)
+
+		nextPage := d.conf.DefaultPage
+		if _, ok := d.conf.Pages[d.activePageName]; ok {
+			nextPage = d.activePageName
+		}
+
+		if err := d.togglePage(nextPage); err != nil {
+			return fmt.Errorf("reloading page on deck %s: %w", d.serial, err)
+		}
 
-	if err := togglePage(nextPage); err != nil {
-		return fmt.Errorf("reloading page: %w", err)
+		d.resetOffTimer()
 	}
 
 	return nil
 }
 
+// watchConfigFiles makes the watcher follow the configuration file and
+// all files included into it when auto-reload is enabled
+func watchConfigFiles() {
+	if configWatcher == nil {
+		return
+	}
+
+	var files []string
+	if userConfig.AutoReload {
+		files = userConfig.Sources()
+	}
+
+	for _, file := range configWatcher.WatchList() {
+		if slices.Contains(files, file) {
+			continue
+		}
+
+		if err := configWatcher.Remove(file); err != nil {
+			logrus.WithError(err).WithField("file", file).Error("Unable to stop watching config file")
+		}
+	}
+
+	for _, file := range files {
+		if err := configWatcher.Add(file); err != nil {
+			logrus.WithError(err).WithField("file", file).Error("Unable to watch config file, auto-reload will not work for it")
+		}
+	}
+}
+
+// triggerActions executes the actions of the key or dial with the given
+// index (-1 for chords and the touch strip) matching the press duration
+//
 //revive:disable-next-line:flag-parameter // does not switch behavior, just denotes whether key was pressed long
-func triggerAction(kd config.KeyDefinition, isLongPress bool) error {
-	for _, a := range kd.Actions {
+func (d *deckState) triggerActions(idx int, actions []config.DynamicElement, isLongPress bool) error {
+	for _, a := range actions {
 		if a.Type == "" {
 			// No type on that action: Invalid
 			continue
@@ -248,7 +271,10 @@ func triggerAction(kd config.KeyDefinition, isLongPress bool) error {
 			continue
 		}
 
-		if err := modules.CallAction(moduleRuntime(), a); err != nil {
+		rt := d.moduleRuntime(d.activePageName)
+		rt.Key = idx
+
+		if err := modules.CallAction(rt, a); err != nil {
 			return fmt.Errorf("calling action: %w", err)
 		}
 	}
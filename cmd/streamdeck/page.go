@@ -3,67 +3,159 @@ package main
 import (
 	"context"
 	"fmt"
+	"image"
 
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules/opts"
 	"github.com/sirupsen/logrus"
 )
 
-func togglePage(page string) (err error) {
-	if activePageCtxCancel != nil {
+func (d *deckState) togglePage(page string) (err error) {
+	if d.activePageCtxCancel != nil {
 		// Ensure old display events are no longer executed
-		activePageCtxCancel()
+		d.activePageCtxCancel()
 	}
 
-	activePage = userConfig.Pages[page]
-	activePageName = page
-	activePageCtx, activePageCtxCancel = context.WithCancel(context.Background())
-	if err = sd.ClearAllKeys(); err != nil {
-		return fmt.Errorf("clearing keys: %w", err)
+	d.activePage = d.conf.Pages[page]
+	d.activePageName = page
+	d.activePageCtx, d.activePageCtxCancel = context.WithCancel(context.Background())
+
+	if d.client.HasDisplay() {
+		keys := d.activePage.GetKeyDefinitions(d.conf)
+
+		if def := d.activePage.GetPanel(d.conf); def.IsDefined() {
+			// Downloading the wallpaper must not block the event loop,
+			// the keys are rendered as soon as it is loaded
+			go func(ctx context.Context) {
+				panel, err := d.loadPanel(ctx, def)
+				switch {
+				case ctx.Err() != nil:
+					return
+
+				case err != nil:
+					// Wallpaper is decoration only, show the page without it
+					d.logger().WithError(err).Error("Unable to load panel wallpaper")
+				}
+
+				if err = d.renderKeys(ctx, page, keys, panel); err != nil {
+					d.logger().WithError(err).WithField("page", page).Error("Unable to render keys")
+				}
+			}(d.activePageCtx)
+		} else if err = d.renderKeys(d.activePageCtx, page, keys, nil); err != nil {
+			return fmt.Errorf("rendering keys: %w", err)
+		}
 	}
 
-	for idx, kd := range activePage.GetKeyDefinitions(userConfig) {
-		if kd.Display.Type == "" {
-			continue
+	for idx, kd := range d.activePage.GetKeyDefinitions(d.conf) {
+		if len(kd.States) > 0 && len(kd.StateCommand.Command) > 0 {
+			go d.syncKeyState(d.activePageCtx, page, idx, kd)
 		}
+	}
+
+	if err = d.renderLCD(d.activePageCtx, page); err != nil {
+		return fmt.Errorf("rendering LCD: %w", err)
+	}
 
-		go func(idx int, kd config.KeyDefinition) {
-			localCtx := activePageCtx
-			keyLogger := logrus.WithFields(logrus.Fields{
-				"key":  idx,
-				"page": activePageName,
-			})
+	if err = d.renderInfoBar(d.activePageCtx, page); err != nil {
+		return fmt.Errorf("rendering info bar: %w", err)
+	}
 
-			if err := modules.CallDisplayElement(localCtx, idx, moduleRuntime(), kd); err != nil {
-				keyLogger.WithError(err).Error("Unable to execute display element")
+	if len(d.pageStack) == 0 || d.pageStack[0] != page {
+		d.pageStack = append([]string{page}, d.pageStack...)
+	}
 
-				if err := modules.CallErrorDisplayElement(localCtx, idx, moduleRuntime()); err != nil {
-					keyLogger.WithError(err).Error("Unable to execute error display element")
-				}
-			}
-		}(idx, kd)
+	if len(d.pageStack) > maxPageStackSize {
+		d.pageStack = d.pageStack[:maxPageStackSize]
 	}
 
-	if len(pageStack) == 0 || pageStack[0] != page {
-		pageStack = append([]string{page}, pageStack...)
+	return nil
+}
+
+// renderKeys starts the displays of the keys on the page and draws the
+// wallpaper onto or clears all other keys
+func (d *deckState) renderKeys(ctx context.Context, page string, keys map[int]config.KeyDefinition, panel *panelState) error {
+	client := d.currentClient()
+
+	// New content is drawn over the old one, only keys without a
+	// display are cleared. Unchanged keys are skipped by the client.
+	var wallpaperKeys []int
+	for idx := range client.NumKeys() + client.NumTouchKeys() {
+		if ctx.Err() != nil {
+			// Page was switched meanwhile
+			return nil
+		}
+
+		if kd, ok := keys[idx]; ok && len(kd.States) > 0 {
+			go d.renderKeyStates(ctx, page, idx, kd, func() image.Image { return panel.tile(idx) })
+			continue
+		}
+
+		if kd, ok := keys[idx]; ok && kd.Display.Type != "" {
+			go d.renderDisplay(ctx, page, idx, d, kd, "key", func() image.Image { return panel.tile(idx) })
+			continue
+		}
+
+		if tile := panel.tile(idx); tile != nil {
+			wallpaperKeys = append(wallpaperKeys, idx)
+			if err := client.FillImage(idx, tile); err != nil {
+				return fmt.Errorf("drawing wallpaper on key %d: %w", idx, err)
+			}
+			continue
+		}
+
+		if err := client.ClearKey(idx); err != nil {
+			return fmt.Errorf("clearing key %d: %w", idx, err)
+		}
 	}
 
-	if len(pageStack) > maxPageStackSize {
-		pageStack = pageStack[:maxPageStackSize]
+	if panel != nil && panel.animated() {
+		go d.animatePanel(ctx, panel, wallpaperKeys)
 	}
 
+	frames, queue, encoded := client.FrameStats(), client.WriteQueueStats(), client.EncodeCacheStats()
+	d.logger().WithFields(logrus.Fields{
+		"coalesced":   queue.Coalesced,
+		"encode_hits": encoded.Hits,
+		"latency_avg": queue.AvgLatency,
+		"latency_max": queue.MaxLatency,
+		"queued":      queue.Depth,
+		"sent":        frames.Sent,
+		"skipped":     frames.Skipped,
+	}).Debug("Key write stats")
+
 	return nil
 }
 
-func toggleRelativePage(rel int) (err error) {
-	if rel >= len(pageStack) {
+func (d *deckState) renderDisplay(ctx context.Context, page string, idx int, deck opts.Deck, kd config.KeyDefinition, target string, background func() image.Image) {
+	rt := d.moduleRuntime(page)
+	rt.Background = background
+	rt.Deck = deck
+	rt.Key = idx
+
+	keyLogger := d.logger().WithFields(logrus.Fields{
+		target: idx,
+		"page": page,
+	})
+
+	if err := modules.CallDisplayElement(ctx, idx, rt, kd); err != nil {
+		keyLogger.WithError(err).Error("Unable to execute display element")
+
+		if err := modules.CallErrorDisplayElement(ctx, idx, rt); err != nil {
+			keyLogger.WithError(err).Error("Unable to execute error display element")
+		}
+	}
+}
+
+func (d *deckState) toggleRelativePage(rel int) (err error) {
+	if rel >= len(d.pageStack) {
 		return fmt.Errorf("relative page %d out of range", rel)
 	}
 
-	nextPage := pageStack[rel]
-	pageStack = pageStack[rel+1:]
+	nextPage := d.pageStack[rel]
+	d.pageStack = d.pageStack[rel+1:]
 
-	if err = togglePage(nextPage); err != nil {
+	if err = d.togglePage(nextPage); err != nil {
 		return fmt.Errorf("switching relative page: %w", err)
 	}
 
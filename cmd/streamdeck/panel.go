@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"sync"
+	"time"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/frames"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/helpers"
+)
+
+// panelState contains the wallpaper split into key tiles for every
+// frame of the (animated) wallpaper
+type panelState struct {
+	anim  frames.Animation
+	tiles [][]image.Image
+
+	lock  sync.RWMutex
+	frame int
+}
+
+// loadPanel loads the wallpaper of the panel definition and splits
+// all its frames into the tiles for the keys of the deck
+func (d *deckState) loadPanel(ctx context.Context, def config.PanelDefinition) (*panelState, error) {
+	filename := def.Path
+	if filename == "" {
+		var err error
+		if filename, err = helpers.CachedDownload(ctx, def.URL); err != nil {
+			return nil, fmt.Errorf("downloading wallpaper: %w", err)
+		}
+	}
+
+	anim, err := frames.Load(filename)
+	if err != nil {
+		return nil, fmt.Errorf("loading wallpaper: %w", err)
+	}
+
+	panel := &panelState{anim: anim}
+	for i, frame := range anim.Frames {
+		panel.tiles = append(panel.tiles, d.currentClient().SplitPanel(frame, def.Gap))
+
+		if def.FrameRate > 0 {
+			panel.anim.Delays[i] = time.Duration(float64(time.Second) / def.FrameRate)
+		}
+	}
+
+	return panel, nil
+}
+
+// animated reports whether the wallpaper has more than one frame
+func (p *panelState) animated() bool { return len(p.tiles) > 1 }
+
+// tile returns the part of the current frame shown on the key or nil
+// for keys not being part of the panel
+func (p *panelState) tile(keyIdx int) image.Image {
+	if p == nil {
+		return nil
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if keyIdx < 0 || keyIdx >= len(p.tiles[p.frame]) {
+		return nil
+	}
+
+	return p.tiles[p.frame][keyIdx]
+}
+
+// animatePanel plays the frames of an animated wallpaper on the given
+// keys in sync with the animated key displays until the page context
+// is cancelled
+func (d *deckState) animatePanel(ctx context.Context, panel *panelState, keys []int) {
+	err := panel.anim.Play(ctx, frames.DefaultClock, func(frame int) error {
+		panel.lock.Lock()
+		panel.frame = frame
+		panel.lock.Unlock()
+
+		for _, idx := range keys {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("page context cancelled: %w", err)
+			}
+
+			if err := d.currentClient().FillImage(idx, panel.tile(idx)); err != nil {
+				d.logger().WithError(err).WithField("key", idx).Error("Unable to draw wallpaper frame")
+			}
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(ctx.Err(), context.Canceled) {
+		d.logger().WithError(err).Error("Unable to play wallpaper animation")
+	}
+}
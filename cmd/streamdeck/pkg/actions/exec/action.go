@@ -28,8 +28,8 @@ type (
 )
 
 // Execute runs the configured command.
-func (Action) Execute(_ opts.Runtime, atts config.DynamicAttributes) (err error) {
-	attributes, err := config.DecodeAttributes[Attrs](atts)
+func (Action) Execute(devs opts.Runtime, atts config.DynamicAttributes) (err error) {
+	attributes, err := opts.DecodeAttributes[Attrs](devs, atts)
 	if err != nil {
 		return fmt.Errorf("decoding attributes: %w", err)
 	}
@@ -30,7 +30,7 @@ type (
 //
 //nolint:gocyclo // only pressing a few keys
 func (a Action) Execute(devs opts.Runtime, atts config.DynamicAttributes) (err error) {
-	attributes, err := config.DecodeAttributes[Attrs](atts)
+	attributes, err := opts.DecodeAttributes[Attrs](devs, atts)
 	if err != nil {
 		return fmt.Errorf("decoding attributes: %w", err)
 	}
@@ -39,6 +39,10 @@ func (a Action) Execute(devs opts.Runtime, atts config.DynamicAttributes) (err e
 		return fmt.Errorf("no key_codes array present")
 	}
 
+	if devs.Keyboard == nil {
+		return fmt.Errorf("no keyboard available")
+	}
+
 	var execCodes []uint16
 	for _, k := range attributes.KeyCodes {
 		if k < 0 || k > 65535 { //revive:disable-line:add-constant // single-use boundary
@@ -13,8 +13,10 @@ type (
 	// Action switches to another page.
 	Action struct{}
 
-	// Attrs contains configuration for the page action.
+	// Attrs contains configuration for the page action. Deck optionally
+	// selects another deck by its serial to switch the page on.
 	Attrs struct {
+		Deck     string `json:"deck,omitempty" yaml:"deck,omitempty"`
 		Name     string `json:"name,omitempty" yaml:"name,omitempty"`
 		Relative int    `json:"relative,omitempty" yaml:"relative,omitempty"`
 	}
@@ -22,11 +24,17 @@ type (
 
 // Execute switches to the configured page or relative page.
 func (Action) Execute(dev opts.Runtime, atts config.DynamicAttributes) (err error) {
-	attributes, err := config.DecodeAttributes[Attrs](atts)
+	attributes, err := opts.DecodeAttributes[Attrs](dev, atts)
 	if err != nil {
 		return fmt.Errorf("decoding attributes: %w", err)
 	}
 
+	if attributes.Deck != "" {
+		if dev, err = dev.DeckRuntime(attributes.Deck); err != nil {
+			return fmt.Errorf("getting deck runtime: %w", err)
+		}
+	}
+
 	if attributes.Name != "" {
 		if err = dev.TogglePage(attributes.Name); err != nil {
 			return fmt.Errorf("switching page: %w", err)
@@ -0,0 +1,66 @@
+// Package state provides actions changing the shared variable store.
+package state
+
+import (
+	"fmt"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules/opts"
+)
+
+type (
+	// Action changes a value of the shared variable store.
+	Action struct{}
+
+	// Attrs contains configuration for the state action. The operation
+	// is one of "set" (default) storing the value, "toggle" cycling
+	// through the values (or "true" and "false") and "increment" adding
+	// the step (default 1) to the integer value.
+	Attrs struct {
+		Key       string   `json:"key,omitempty" yaml:"key,omitempty"`
+		Operation string   `json:"operation,omitempty" yaml:"operation,omitempty"`
+		Step      *int64   `json:"step,omitempty" yaml:"step,omitempty"`
+		Value     string   `json:"value,omitempty" yaml:"value,omitempty"`
+		Values    []string `json:"values,omitempty" yaml:"values,omitempty"`
+	}
+)
+
+// Execute applies the operation to the value of the configured key.
+func (Action) Execute(devs opts.Runtime, atts config.DynamicAttributes) (err error) {
+	attributes, err := opts.DecodeAttributes[Attrs](devs, atts)
+	if err != nil {
+		return fmt.Errorf("decoding attributes: %w", err)
+	}
+
+	if attributes.Key == "" {
+		return fmt.Errorf("no key supplied")
+	}
+
+	if devs.State == nil {
+		return fmt.Errorf("no state store available")
+	}
+
+	switch attributes.Operation {
+	case "", "set":
+		err = devs.State.Set(attributes.Key, attributes.Value)
+
+	case "toggle":
+		_, err = devs.State.Toggle(attributes.Key, attributes.Values...)
+
+	case "increment":
+		step := int64(1)
+		if attributes.Step != nil {
+			step = *attributes.Step
+		}
+		_, err = devs.State.Increment(attributes.Key, step)
+
+	default:
+		return fmt.Errorf("unknown operation %q", attributes.Operation)
+	}
+
+	if err != nil {
+		return fmt.Errorf("updating state: %w", err)
+	}
+
+	return nil
+}
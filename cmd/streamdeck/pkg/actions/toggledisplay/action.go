@@ -3,37 +3,62 @@ package toggledisplay
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules/opts"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/state"
 )
 
 // Action toggles the StreamDeck display brightness.
 type Action struct{}
 
-var (
-	actionToggleDisplayPreviousBrightness int
-	currentBrightness                     = -1
-)
-
-// Execute toggles between the previous brightness and display-off.
+// Execute toggles between the previous brightness and display-off. The
+// brightness to restore is kept in the state store of the runtime.
 func (Action) Execute(devs opts.Runtime, _ config.DynamicAttributes) error {
-	if currentBrightness == -1 {
-		// Initialize on first call
-		currentBrightness = devs.Conf.DefaultBrightness
+	if devs.State == nil {
+		return fmt.Errorf("no state store available")
 	}
 
+	var (
+		currentKey  = state.BrightnessKey(devs.Serial)
+		previousKey = "toggle_display.previous." + devs.Serial
+	)
+
+	currentBrightness := storedInt(devs.State, currentKey, devs.Conf.DefaultBrightness)
+
 	var newB int
 	if currentBrightness > 0 {
-		actionToggleDisplayPreviousBrightness = currentBrightness
+		if err := devs.State.Set(previousKey, strconv.Itoa(currentBrightness)); err != nil {
+			return fmt.Errorf("storing brightness: %w", err)
+		}
 	} else {
-		newB = actionToggleDisplayPreviousBrightness
+		newB = storedInt(devs.State, previousKey, 0)
 	}
 
 	if err := devs.Deck.SetBrightness(newB); err != nil {
 		return fmt.Errorf("setting brightness: %w", err)
 	}
-	currentBrightness = newB
+
+	if err := devs.State.Set(currentKey, strconv.Itoa(newB)); err != nil {
+		return fmt.Errorf("storing brightness: %w", err)
+	}
 
 	return nil
 }
+
+// storedInt returns the integer value from the store or the fallback
+// if the value is not set or not an integer
+func storedInt(s *state.Store, name string, fallback int) int {
+	v, ok := s.Get(name)
+	if !ok {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fallback
+	}
+
+	return n
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+type (
+	// Position is the location of a value within the configuration file
+	// or one of the files included into it
+	Position struct {
+		File   string
+		Line   int
+		Column int
+	}
+
+	// Problem describes an invalid value of the configuration file
+	Problem struct {
+		Position
+		Message string
+
+		node *yaml.Node
+	}
+
+	// nodeVisitor is called for every value while checking a node tree
+	// with the path of the value (field names, map keys and sequence
+	// indices), the key node for values of mappings and the value node
+	nodeVisitor func(path []string, key, value *yaml.Node, t reflect.Type)
+)
+
+var dynamicAttributesType = reflect.TypeFor[DynamicAttributes]()
+
+// NodePosition returns the position of the node
+func NodePosition(node *yaml.Node) Position {
+	if node == nil {
+		return Position{}
+	}
+
+	return Position{Line: node.Line, Column: node.Column}
+}
+
+// NewProblem creates a problem located at the node
+func NewProblem(node *yaml.Node, format string, args ...any) Problem {
+	return Problem{Position: NodePosition(node), Message: fmt.Sprintf(format, args...), node: node}
+}
+
+func (p Problem) String() string {
+	if p.File != "" {
+		return fmt.Sprintf("%s:%d:%d: %s", p.File, p.Line, p.Column, p.Message)
+	}
+
+	return fmt.Sprintf("line %d column %d: %s", p.Line, p.Column, p.Message)
+}
+
+// CheckNode reports the values of the node not fitting into v: fields
+// unknown to structs, mappings and sequences in place of other kinds
+// and values not to be decoded into their field. Dynamic attributes
+// are not checked.
+func CheckNode(node *yaml.Node, v any) []Problem {
+	return checkNode(nil, nil, node, reflect.TypeOf(v), nil)
+}
+
+// MappingValue returns the value of the key in the mapping node or
+// nil if the node is no mapping or the key is not present
+func MappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+//nolint:gocyclo // handles all kinds of nodes in one place
+func checkNode(path []string, key, node *yaml.Node, t reflect.Type, visit nodeVisitor) (problems []Problem) {
+	if node == nil || t == nil {
+		return nil
+	}
+
+	for node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if visit != nil {
+		visit(path, key, node, t)
+	}
+
+	if t == dynamicAttributesType || t.Kind() == reflect.Interface ||
+		(node.Kind == yaml.ScalarNode && node.Tag == "!!null") {
+		// Attributes are checked by their module, null is the zero value
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.PkgPath() == "time" {
+			break
+		}
+
+		if node.Kind != yaml.MappingNode {
+			return []Problem{NewProblem(node, "expected mapping, found %s", kindName(node))}
+		}
+
+		fields := structFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+
+			field, ok := fields[k.Value]
+			if !ok {
+				problems = append(problems, NewProblem(k, "unknown field %q", k.Value))
+				continue
+			}
+
+			problems = append(problems, checkNode(appendPath(path, k.Value), k, v, field.Type, visit)...)
+		}
+
+		return problems
+
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return []Problem{NewProblem(node, "expected mapping, found %s", kindName(node))}
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+
+			if err := k.Decode(reflect.New(t.Key()).Interface()); err != nil {
+				problems = append(problems, NewProblem(k, "invalid key %q, expected %s", k.Value, typeName(t.Key())))
+				continue
+			}
+
+			problems = append(problems, checkNode(appendPath(path, k.Value), k, v, t.Elem(), visit)...)
+		}
+
+		return problems
+
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return []Problem{NewProblem(node, "expected sequence, found %s", kindName(node))}
+		}
+
+		if t.Kind() == reflect.Array && len(node.Content) > t.Len() {
+			problems = append(problems, NewProblem(node, "expected at most %d values, found %d", t.Len(), len(node.Content)))
+		}
+
+		for i, v := range node.Content {
+			problems = append(problems, checkNode(appendPath(path, strconv.Itoa(i)), nil, v, t.Elem(), visit)...)
+		}
+
+		return problems
+	}
+
+	if node.Kind != yaml.ScalarNode {
+		return []Problem{NewProblem(node, "expected %s, found %s", typeName(t), kindName(node))}
+	}
+
+	if err := node.Decode(reflect.New(t).Interface()); err != nil {
+		return []Problem{NewProblem(node, "invalid value %q, expected %s", node.Value, typeName(t))}
+	}
+
+	return nil
+}
+
+// appendPath returns a new path not sharing its memory with the
+// parent path to be kept by visitors
+func appendPath(path []string, elem string) []string {
+	return append(path[:len(path):len(path)], elem)
+}
+
+func kindName(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.SequenceNode:
+		return "sequence"
+	default:
+		return "value " + strconv.Quote(node.Value)
+	}
+}
+
+// structFields returns the fields of the struct by their YAML name
+func structFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		switch {
+		case name == "-":
+			continue
+		case strings.Contains(opts, "inline") && field.Type.Kind() == reflect.Struct:
+			maps.Copy(fields, structFields(field.Type))
+			continue
+		case name == "":
+			name = strings.ToLower(field.Name)
+		}
+
+		fields[name] = field
+	}
+
+	return fields
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Int64:
+		if t.PkgPath() == "time" && t.Name() == "Duration" {
+			return "duration"
+		}
+		return "integer"
+	case reflect.String:
+		return "string"
+	default:
+		return t.String()
+	}
+}
@@ -7,10 +7,9 @@ import (
 	"os"
 	"time"
 
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/schema"
 	"github.com/sirupsen/logrus"
 	"go.yaml.in/yaml/v3"
-
-	"github.com/Luzifer/streamdeck/v2"
 )
 
 const defaultLongPressDuration = 500 * time.Millisecond
@@ -37,55 +36,140 @@ type (
 
 	// File is the top-level StreamDeck configuration.
 	File struct {
-		AutoReload        bool            `json:"auto_reload" yaml:"auto_reload"`
-		CaptionBorder     int             `json:"caption_border" yaml:"caption_border"`
-		CaptionColor      [4]int          `json:"caption_color" yaml:"caption_color"`
-		CaptionFont       string          `json:"caption_font" yaml:"caption_font"`
-		CaptionFontSize   float64         `json:"caption_font_size" yaml:"caption_font_size"`
-		CaptionPosition   CaptionPosition `json:"caption_position" yaml:"caption_position"`
-		DefaultBrightness int             `json:"default_brightness" yaml:"default_brightness"`
-		DefaultPage       string          `json:"default_page" yaml:"default_page"`
-		DisplayOffTime    time.Duration   `json:"display_off_time" yaml:"display_off_time"`
-		LongPressDuration time.Duration   `json:"long_press_duration" yaml:"long_press_duration"`
-		Pages             map[string]Page `json:"pages" yaml:"pages"`
-		RenderFont        string          `json:"render_font" yaml:"render_font"`
-	}
-
-	// KeyDefinition defines display and actions for one key.
+		AutoReload        bool                      `json:"auto_reload" yaml:"auto_reload"`
+		CaptionBorder     int                       `json:"caption_border" yaml:"caption_border"`
+		CaptionColor      [4]int                    `json:"caption_color" yaml:"caption_color"`
+		CaptionFont       string                    `json:"caption_font" yaml:"caption_font"`
+		CaptionFontSize   float64                   `json:"caption_font_size" yaml:"caption_font_size"`
+		CaptionPosition   CaptionPosition           `json:"caption_position" yaml:"caption_position"`
+		Decks             map[string]DeckDefinition `json:"decks" yaml:"decks"`
+		DefaultBrightness int                       `json:"default_brightness" yaml:"default_brightness"`
+		DefaultPage       string                    `json:"default_page" yaml:"default_page"`
+		DisplayOffTime    time.Duration             `json:"display_off_time" yaml:"display_off_time"`
+		Include           []string                  `json:"include" yaml:"include"`
+		LongPressDuration time.Duration             `json:"long_press_duration" yaml:"long_press_duration"`
+		Pages             map[string]Page           `json:"pages" yaml:"pages"`
+		RenderFont        string                    `json:"render_font" yaml:"render_font"`
+		Serials           []string                  `json:"serials" yaml:"serials"`
+		Templates         map[string]KeyDefinition  `json:"templates" yaml:"templates"`
+
+		// sources contains the files the configuration was read from
+		sources []string
+	}
+
+	// ChordDefinition defines actions triggered by pressing a set of
+	// keys together. The actions of the single keys are not triggered
+	// when releasing keys of a chord.
+	ChordDefinition struct {
+		Keys    []int            `json:"keys" yaml:"keys"`
+		Actions []DynamicElement `json:"actions" yaml:"actions"`
+	}
+
+	// DeckDefinition configures one deck identified by its serial. Its
+	// pages are added to the global pages (replacing pages with the
+	// same name) and its default page replaces the global one.
+	DeckDefinition struct {
+		DefaultPage string          `json:"default_page" yaml:"default_page"`
+		Pages       map[string]Page `json:"pages" yaml:"pages"`
+	}
+
+	// DialDefinition defines display and actions for one dial. The
+	// display is rendered onto the LCD strip region above the dial,
+	// actions are triggered by pressing the dial.
+	DialDefinition struct {
+		Display     DynamicElement   `json:"display" yaml:"display"`
+		Actions     []DynamicElement `json:"actions" yaml:"actions"`
+		RotateLeft  []DynamicElement `json:"rotate_left" yaml:"rotate_left"`
+		RotateRight []DynamicElement `json:"rotate_right" yaml:"rotate_right"`
+	}
+
+	// KeyDefinition defines display and actions for one key. A key
+	// using a template is expanded from the template while loading,
+	// display and actions given for the key replace those of the
+	// template. A key having states shows the display of its current
+	// state and advances to the next state when pressed, the state is
+	// kept in the state store under the state key (defaults to a key
+	// specific to the deck, page and key index).
 	KeyDefinition struct {
+		Display      DynamicElement    `json:"display" yaml:"display"`
+		Actions      []DynamicElement  `json:"actions" yaml:"actions"`
+		Template     string            `json:"template" yaml:"template"`
+		Params       map[string]string `json:"params" yaml:"params"`
+		States       []KeyState        `json:"states" yaml:"states"`
+		StateCommand StateCommand      `json:"state_command" yaml:"state_command"`
+		StateKey     string            `json:"state_key" yaml:"state_key"`
+	}
+
+	// KeyState defines one state of a multi-state key. The display
+	// replaces the display of the key (if set), the actions are
+	// executed after the actions of the key. States without a name are
+	// named by their index.
+	KeyState struct {
+		Name    string           `json:"name" yaml:"name"`
 		Display DynamicElement   `json:"display" yaml:"display"`
 		Actions []DynamicElement `json:"actions" yaml:"actions"`
 	}
 
-	// Page contains key definitions and optional overlay or underlay references.
+	// StateCommand reconciles the state of a multi-state key with an
+	// external source: the command is executed when showing the page
+	// and then at the interval (if set), its output is the name of the
+	// state to switch to.
+	StateCommand struct {
+		Command  []string      `json:"command" yaml:"command"`
+		Interval time.Duration `json:"interval" yaml:"interval"`
+	}
+
+	// Page contains key definitions and optional overlay or underlay
+	// references. On devices with touch keys these are addressed by the
+	// key indices following the regular keys. The info bar display is
+	// only rendered on devices having an info bar.
 	Page struct {
-		Keys     map[int]KeyDefinition `json:"keys" yaml:"keys"`
-		Overlay  string                `json:"overlay" yaml:"overlay"`
-		Underlay string                `json:"underlay" yaml:"underlay"`
+		Chords     []ChordDefinition      `json:"chords" yaml:"chords"`
+		Dials      map[int]DialDefinition `json:"dials" yaml:"dials"`
+		InfoBar    DynamicElement         `json:"info_bar" yaml:"info_bar"`
+		Keys       map[int]KeyDefinition  `json:"keys" yaml:"keys"`
+		Overlay    string                 `json:"overlay" yaml:"overlay"`
+		Panel      PanelDefinition        `json:"panel" yaml:"panel"`
+		TouchStrip TouchStripDefinition   `json:"touch_strip" yaml:"touch_strip"`
+		Underlay   string                 `json:"underlay" yaml:"underlay"`
 	}
-)
 
-// Load reads, validates, and expands a configuration file.
-func Load(confFile string, deck *streamdeck.Client) (f File, err error) {
-	userConfFile, err := os.Open(confFile) //#nosec:G304 // intended to read specified config file
-	if err != nil {
-		return f, fmt.Errorf("opening config: %w", err)
+	// PanelDefinition defines a wallpaper image spanning all keys. Keys
+	// having a display get their part of the wallpaper as background.
+	// Animated GIFs are played using their frame delays unless a frame
+	// rate is set. Displays pick up the current wallpaper frame only
+	// when they are rendered, so keys with a display not refreshing
+	// themselves keep the frame shown when the page was opened.
+	PanelDefinition struct {
+		FrameRate float64 `json:"frame_rate" yaml:"frame_rate"`
+		Gap       int     `json:"gap" yaml:"gap"`
+		Path      string  `json:"path" yaml:"path"`
+		URL       string  `json:"url" yaml:"url"`
 	}
-	defer func() {
-		if err := userConfFile.Close(); err != nil {
-			logrus.WithError(err).Error("closing config file (leaked fd)")
-		}
-	}()
 
-	var rawConf yaml.Node
+	// TouchStripDefinition defines display and actions for the touch
+	// strip. The display covers the whole LCD strip and is only rendered
+	// when no dial of the page defines a display. Actions are triggered
+	// by tapping the strip (long_press for long touches).
+	TouchStripDefinition struct {
+		Display    DynamicElement   `json:"display" yaml:"display"`
+		Actions    []DynamicElement `json:"actions" yaml:"actions"`
+		SwipeLeft  []DynamicElement `json:"swipe_left" yaml:"swipe_left"`
+		SwipeRight []DynamicElement `json:"swipe_right" yaml:"swipe_right"`
+	}
+)
 
-	decoder := yaml.NewDecoder(userConfFile)
-	if err = decoder.Decode(&rawConf); err != nil {
-		return f, fmt.Errorf("parsing config: %w", err)
+// Load reads, validates, and expands a configuration file and the files
+// it includes. Use ForDeck to retrieve the configuration for a specific
+// deck.
+func Load(confFile string) (f File, err error) {
+	rawConf, l, err := loadTree(confFile)
+	if err != nil {
+		return f, err
 	}
 
-	if err = expandEnvVariables(&rawConf); err != nil {
-		return f, fmt.Errorf("expanding env variables: %w", err)
+	if len(l.problems) > 0 {
+		return f, fmt.Errorf("expanding templates: %s", l.locate(l.problems[0]))
 	}
 
 	buf := new(bytes.Buffer)
@@ -93,7 +177,7 @@ func Load(confFile string, deck *streamdeck.Client) (f File, err error) {
 		return f, fmt.Errorf("encoding expanded config: %w", err)
 	}
 
-	decoder = yaml.NewDecoder(buf)
+	decoder := yaml.NewDecoder(buf)
 	f = New()
 
 	decoder.KnownFields(true)
@@ -101,11 +185,36 @@ func Load(confFile string, deck *streamdeck.Client) (f File, err error) {
 		return f, fmt.Errorf("parsing config: %w", err)
 	}
 
-	applySystemPages(deck, &f)
+	f.sources = l.files
 
 	return f, nil
 }
 
+// loadNode reads the configuration file into a node tree keeping the
+// position of all values and expands the references to environment
+// variables and secrets
+func loadNode(confFile string) (rawConf yaml.Node, err error) {
+	userConfFile, err := os.Open(confFile) //#nosec:G304 // intended to read specified config file
+	if err != nil {
+		return rawConf, fmt.Errorf("opening config: %w", err)
+	}
+	defer func() {
+		if err := userConfFile.Close(); err != nil {
+			logrus.WithError(err).Error("closing config file (leaked fd)")
+		}
+	}()
+
+	if err = yaml.NewDecoder(userConfFile).Decode(&rawConf); err != nil {
+		return rawConf, fmt.Errorf("parsing config: %w", err)
+	}
+
+	if err = expandReferences(&rawConf); err != nil {
+		return rawConf, fmt.Errorf("expanding references: %w", err)
+	}
+
+	return rawConf, nil
+}
+
 // New returns a configuration populated with defaults.
 func New() File {
 	return File{
@@ -113,3 +222,14 @@ func New() File {
 		LongPressDuration: defaultLongPressDuration,
 	}
 }
+
+// Sources returns the configuration file and all files included into it
+func (f File) Sources() []string { return f.sources }
+
+// JSONSchema restricts the caption position to its known values
+func (CaptionPosition) JSONSchema() schema.Schema {
+	return schema.Schema{
+		"type": "string",
+		"enum": []string{CaptionPositionEmpty, CaptionPositionBottom, CaptionPositionTop},
+	}
+}
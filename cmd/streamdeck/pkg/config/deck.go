@@ -0,0 +1,48 @@
+package config
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/Luzifer/streamdeck/v2"
+)
+
+// ForDeck returns the configuration for the deck with the given serial:
+// the pages defined for the deck are merged into the global pages and
+// its default page replaces the global one. System pages are added
+// matching the capabilities of the given deck.
+func (f File) ForDeck(serial string, deck *streamdeck.Client) File {
+	out := f
+	out.Pages = maps.Clone(f.Pages)
+	if out.Pages == nil {
+		out.Pages = make(map[string]Page)
+	}
+
+	if dd, ok := f.Decks[serial]; ok {
+		maps.Copy(out.Pages, dd.Pages)
+
+		if dd.DefaultPage != "" {
+			out.DefaultPage = dd.DefaultPage
+		}
+	}
+
+	applySystemPages(deck, &out)
+
+	return out
+}
+
+// UsesDeck reports whether the deck with the given serial should be
+// driven: when serials are configured only those decks are used, when
+// decks are configured only the configured ones, otherwise all decks.
+func (f File) UsesDeck(serial string) bool {
+	if len(f.Serials) > 0 && !slices.Contains(f.Serials, serial) {
+		return false
+	}
+
+	if len(f.Decks) == 0 {
+		return true
+	}
+
+	_, ok := f.Decks[serial]
+	return ok
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Luzifer/streamdeck/v2"
+)
+
+func TestForDeck(t *testing.T) {
+	t.Parallel()
+
+	fake, err := streamdeck.NewFakeDeck(streamdeck.StreamDeckMini)
+	require.NoError(t, err)
+
+	client, err := streamdeck.NewWithTransport(streamdeck.StreamDeckMini, fake)
+	require.NoError(t, err)
+
+	var (
+		global = Page{Keys: map[int]KeyDefinition{0: {Display: DynamicElement{Type: "global"}}}}
+		own    = Page{Keys: map[int]KeyDefinition{0: {Display: DynamicElement{Type: "own"}}}}
+	)
+
+	cfg := File{
+		DefaultPage: "main",
+		Decks: map[string]DeckDefinition{
+			"AL01": {DefaultPage: "obs", Pages: map[string]Page{"main": own, "obs": own}},
+		},
+		Pages: map[string]Page{"main": global, "other": global},
+	}
+
+	deckCfg := cfg.ForDeck("AL01", client)
+	assert.Equal(t, "obs", deckCfg.DefaultPage)
+	assert.Equal(t, own, deckCfg.Pages["main"])
+	assert.Equal(t, own, deckCfg.Pages["obs"])
+	assert.Equal(t, global, deckCfg.Pages["other"])
+	assert.Len(t, deckCfg.Pages["@@blank"].Keys, client.NumKeys())
+
+	otherCfg := cfg.ForDeck("AL02", client)
+	assert.Equal(t, "main", otherCfg.DefaultPage)
+	assert.Equal(t, global, otherCfg.Pages["main"])
+	assert.NotContains(t, otherCfg.Pages, "obs")
+
+	// Global config must not be modified by deck specific views
+	assert.NotContains(t, cfg.Pages, "@@blank")
+	assert.NotContains(t, cfg.Pages, "obs")
+}
+
+func TestUsesDeck(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, File{}.UsesDeck("AL01"))
+
+	cfg := File{Decks: map[string]DeckDefinition{"AL01": {}}}
+	assert.True(t, cfg.UsesDeck("AL01"))
+	assert.False(t, cfg.UsesDeck("AL02"))
+
+	cfg = File{Serials: []string{"AL02", "AL03"}}
+	assert.False(t, cfg.UsesDeck("AL01"))
+	assert.True(t, cfg.UsesDeck("AL02"))
+
+	cfg.Decks = map[string]DeckDefinition{"AL02": {}}
+	assert.True(t, cfg.UsesDeck("AL02"))
+	assert.False(t, cfg.UsesDeck("AL03"))
+}
@@ -2,66 +2,116 @@ package config
 
 import (
 	"fmt"
-	"os"
-	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/secrets"
 	"go.yaml.in/yaml/v3"
 )
 
-var envVariablePattern = regexp.MustCompile(`\$\{env\.([A-Za-z_][A-Za-z0-9_]*)\}`)
+// templatedPaths are the paths of the values evaluated as Go templates
+// when executing or rendering elements: attributes of all elements and
+// the parameters substituted into the attributes of templates. The
+// wildcard matches any name or list index.
+var templatedPaths = func() (paths [][]string) {
+	var (
+		pages = []string{"pages.*", "decks.*.pages.*"}
+		keys  = []string{"templates.*"}
+
+		pageElements  = []string{"chords.*.actions.*", "info_bar"}
+		dialElements  = []string{"display", "actions.*", "rotate_left.*", "rotate_right.*"}
+		stripElements = []string{"display", "actions.*", "swipe_left.*", "swipe_right.*"}
+		keyElements   = []string{"display", "actions.*", "states.*.display", "states.*.actions.*"}
+	)
+
+	for _, page := range pages {
+		keys = append(keys, page+".keys.*")
+
+		for _, e := range pageElements {
+			paths = append(paths, strings.Split(page+"."+e+".attributes", "."))
+		}
 
-func expandEnvVariables(node *yaml.Node) error {
-	return expandEnvVariablesWithLookup(node, os.LookupEnv)
-}
+		for _, e := range dialElements {
+			paths = append(paths, strings.Split(page+".dials.*."+e+".attributes", "."))
+		}
 
-func expandEnvVariablesWithLookup(node *yaml.Node, lookup func(string) (string, bool)) error {
-	return walkEnvVariables(node, lookup)
-}
+		for _, e := range stripElements {
+			paths = append(paths, strings.Split(page+".touch_strip."+e+".attributes", "."))
+		}
+	}
 
-func replaceEnvVariables(value string, lookup func(string) (string, bool)) (string, error) {
-	var err error
+	for _, key := range keys {
+		paths = append(paths, strings.Split(key+".params", "."))
 
-	expanded := envVariablePattern.ReplaceAllStringFunc(value, func(match string) string {
-		if err != nil {
-			return match
+		for _, e := range keyElements {
+			paths = append(paths, strings.Split(key+"."+e+".attributes", "."))
 		}
+	}
+
+	return paths
+}()
+
+// expandReferences replaces references to environment variables and
+// secrets (see secrets.Register) in all string values. Values resolved
+// within templated values are kept literal for the template engine.
+func expandReferences(node *yaml.Node) error {
+	return walkStringValues(node, nil)
+}
 
-		parts := envVariablePattern.FindStringSubmatch(match)
-		if len(parts) != 2 {
-			return match
+// isTemplated reports whether the value at the path is part of a
+// templated value
+func isTemplated(path []string) bool {
+	return slices.ContainsFunc(templatedPaths, func(templated []string) bool {
+		if len(path) < len(templated) {
+			return false
 		}
 
-		replacement, ok := lookup(parts[1])
-		if !ok {
-			err = fmt.Errorf("environment variable %q is not set", parts[1])
-			return match
+		for i, seg := range templated {
+			if seg != "*" && seg != path[i] {
+				return false
+			}
 		}
 
-		return replacement
+		return true
 	})
-	if err != nil {
-		return "", err
+}
+
+// templateLiteral turns a resolved value containing template actions
+// into a template printing the value to keep it from being executed
+func templateLiteral(value string) string {
+	if !strings.Contains(value, "{{") {
+		return value
 	}
 
-	return expanded, nil
+	return "{{" + strconv.Quote(value) + "}}"
 }
 
-func walkEnvVariables(node *yaml.Node, lookup func(string) (string, bool)) error {
+// walkStringValues expands the references in all string values below
+// the node found at the path
+func walkStringValues(node *yaml.Node, path []string) error {
 	if node == nil {
 		return nil
 	}
 
 	switch node.Kind {
-	case yaml.DocumentNode, yaml.SequenceNode:
+	case yaml.DocumentNode:
 		for _, child := range node.Content {
-			if err := walkEnvVariables(child, lookup); err != nil {
+			if err := walkStringValues(child, path); err != nil {
+				return err
+			}
+		}
+
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			if err := walkStringValues(child, append(slices.Clip(path), strconv.Itoa(i))); err != nil {
 				return err
 			}
 		}
 
 	case yaml.MappingNode:
 		for i := 1; i < len(node.Content); i += 2 {
-			if err := walkEnvVariables(node.Content[i], lookup); err != nil {
+			if err := walkStringValues(node.Content[i], append(slices.Clip(path), node.Content[i-1].Value)); err != nil {
 				return err
 			}
 		}
@@ -71,9 +121,14 @@ func walkEnvVariables(node *yaml.Node, lookup func(string) (string, bool)) error
 			return nil
 		}
 
-		expanded, err := replaceEnvVariables(node.Value, lookup)
+		var quote func(string) string
+		if isTemplated(path) {
+			quote = templateLiteral
+		}
+
+		expanded, err := secrets.ExpandQuoted(node.Value, quote)
 		if err != nil {
-			return err
+			return fmt.Errorf("line %d: %w", node.Line, err)
 		}
 
 		node.Value = expanded
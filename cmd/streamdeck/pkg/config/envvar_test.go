@@ -8,32 +8,24 @@ import (
 	"go.yaml.in/yaml/v3"
 )
 
-func TestExpandEnvVariables(t *testing.T) {
-	t.Parallel()
+func TestExpandReferencesEnv(t *testing.T) {
+	t.Setenv("STREAMDECK_TEST_BAR", "bar")
+	t.Setenv("STREAMDECK_TEST_EMPTY", "")
+	t.Setenv("STREAMDECK_TEST_FOO", "foo")
 
 	raw := []byte(`
-plain: "prefix ${env.FOO} suffix"
-multi: "${env.FOO}-${env.BAR}"
-empty: "${env.EMPTY}"
+plain: "prefix ${env.STREAMDECK_TEST_FOO} suffix"
+multi: "${env.STREAMDECK_TEST_FOO}-${env.STREAMDECK_TEST_BAR}"
+empty: "${env.STREAMDECK_TEST_EMPTY}"
+dashed: "${env.STREAMDECK-TEST} is no variable"
 number: 42
-"${env.FOO}": "key stays literal"
+"${env.STREAMDECK_TEST_FOO}": "key stays literal"
 `)
 
 	var node yaml.Node
 	require.NoError(t, yaml.Unmarshal(raw, &node))
 
-	lookup := func(key string) (string, bool) {
-		values := map[string]string{
-			"BAR":   "bar",
-			"EMPTY": "",
-			"FOO":   "foo",
-		}
-
-		value, ok := values[key]
-		return value, ok
-	}
-
-	require.NoError(t, expandEnvVariablesWithLookup(&node, lookup))
+	require.NoError(t, expandReferences(&node))
 
 	var expanded map[string]any
 	require.NoError(t, node.Decode(&expanded))
@@ -41,18 +33,74 @@ number: 42
 	assert.Equal(t, "prefix foo suffix", expanded["plain"])
 	assert.Equal(t, "foo-bar", expanded["multi"])
 	assert.Empty(t, expanded["empty"])
+	assert.Equal(t, "${env.STREAMDECK-TEST} is no variable", expanded["dashed"])
 	assert.Equal(t, 42, expanded["number"])
-	assert.Equal(t, "key stays literal", expanded["${env.FOO}"])
+	assert.Equal(t, "key stays literal", expanded["${env.STREAMDECK_TEST_FOO}"])
 }
 
-func TestExpandEnvVariablesErrorsOnMissingValue(t *testing.T) {
+func TestExpandReferencesErrorsOnMissingEnv(t *testing.T) {
 	t.Parallel()
 
 	var node yaml.Node
-	require.NoError(t, yaml.Unmarshal([]byte(`value: "${env.MISSING}"`), &node))
+	require.NoError(t, yaml.Unmarshal([]byte(`value: "${env.STREAMDECK_TEST_MISSING}"`), &node))
+
+	require.Error(t, expandReferences(&node))
+}
+
+func TestExpandReferencesKeepsTemplatedValuesLiteral(t *testing.T) {
+	t.Setenv("STREAMDECK_TEST_TEMPLATE", "{{ .Page }}")
+
+	raw := []byte(`
+pages:
+  main:
+    keys:
+      0:
+        params:
+          value: "${env.STREAMDECK_TEST_TEMPLATE}"
+        actions:
+          - type: state
+            attributes:
+              value: "${env.STREAMDECK_TEST_TEMPLATE}"
+decks:
+  serial:
+    pages:
+      main:
+        dials:
+          0:
+            display:
+              attributes:
+                text: "${env.STREAMDECK_TEST_TEMPLATE}"
+other:
+  params:
+    value: "${env.STREAMDECK_TEST_TEMPLATE}"
+`)
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal(raw, &node))
+
+	require.NoError(t, expandReferences(&node))
+
+	var expanded struct {
+		Pages map[string]struct {
+			Keys map[int]struct {
+				Params  map[string]string
+				Actions []struct{ Attributes map[string]string }
+			}
+		}
+		Decks map[string]struct {
+			Pages map[string]struct {
+				Dials map[int]struct {
+					Display struct{ Attributes map[string]string }
+				}
+			}
+		}
+		Other struct{ Params map[string]string }
+	}
+	require.NoError(t, node.Decode(&expanded))
 
-	err := expandEnvVariablesWithLookup(&node, func(string) (string, bool) {
-		return "", false
-	})
-	require.Error(t, err)
+	literal := `{{"{{ .Page }}"}}`
+	assert.Equal(t, literal, expanded.Pages["main"].Keys[0].Params["value"])
+	assert.Equal(t, literal, expanded.Pages["main"].Keys[0].Actions[0].Attributes["value"])
+	assert.Equal(t, literal, expanded.Decks["serial"].Pages["main"].Dials[0].Display.Attributes["text"])
+	assert.Equal(t, "{{ .Page }}", expanded.Other.Params["value"])
 }
@@ -0,0 +1,230 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// includeKey is the key of the list of files to include into a file
+const includeKey = "include"
+
+// loader reads a configuration file and its includes into a single
+// node tree remembering the file every node was read from
+type loader struct {
+	expansions map[*yaml.Node]templateUse
+	files      []string
+	origin     map[*yaml.Node]string
+	problems   []Problem
+	stack      []string
+}
+
+// loadTree reads the configuration file and all files it includes and
+// merges them into one document. Includes are merged in the order they
+// are listed (matches of a glob in lexical order), later files override
+// earlier ones and the including file overrides all of its includes.
+// Templates are expanded after merging all files, problems expanding
+// them are collected in the loader.
+func loadTree(confFile string) (rawConf yaml.Node, l *loader, err error) {
+	l = &loader{
+		expansions: make(map[*yaml.Node]templateUse),
+		origin:     make(map[*yaml.Node]string),
+	}
+
+	root, err := l.load(confFile)
+	if err != nil {
+		return rawConf, nil, err
+	}
+
+	l.expandTemplates(root)
+
+	return yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}, l, nil
+}
+
+// load reads the file and returns its content with the included files
+// merged into it
+func (l *loader) load(file string) (*yaml.Node, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, fmt.Errorf("resolving path: %w", err)
+	}
+
+	if slices.Contains(l.stack, abs) {
+		return nil, fmt.Errorf("include cycle: %s", filepath.Base(abs))
+	}
+	l.stack = append(l.stack, abs)
+	defer func() { l.stack = l.stack[:len(l.stack)-1] }()
+
+	doc, err := loadNode(file)
+	if err != nil {
+		return nil, err
+	}
+
+	l.files = append(l.files, file)
+	l.track(&doc, file)
+
+	root := &doc
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+
+	if root.Kind != yaml.MappingNode {
+		// Nothing to include into, structure is reported when decoding
+		return root, nil
+	}
+
+	includes, err := takeIncludes(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(includes) == 0 {
+		return root, nil
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: root.Line, Column: root.Column}
+	l.origin[merged] = file
+
+	for _, pattern := range includes {
+		files, err := resolveInclude(filepath.Dir(file), pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, inc := range files {
+			node, err := l.load(inc)
+			if err != nil {
+				return nil, fmt.Errorf("loading include %q: %w", inc, err)
+			}
+
+			if node.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("loading include %q: expected mapping, found %s", inc, kindName(node))
+			}
+
+			mergeNode(merged, node, reflect.TypeFor[File]())
+		}
+	}
+
+	mergeNode(merged, root, reflect.TypeFor[File]())
+
+	return merged, nil
+}
+
+// track records the file all nodes of the tree were read from
+func (l *loader) track(node *yaml.Node, file string) {
+	l.origin[node] = file
+	for _, child := range node.Content {
+		l.track(child, file)
+	}
+}
+
+// mergeNode merges the mapping src into the mapping dst: fields of
+// structs and entries of maps present in both are merged recursively,
+// all other values (including action and display elements) of src
+// replace those of dst
+func mergeNode(dst, src *yaml.Node, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var fields map[string]reflect.StructField
+	if t.Kind() == reflect.Struct {
+		fields = structFields(t)
+	}
+
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		k, v := src.Content[i], src.Content[i+1]
+
+		idx := -1
+		for j := 0; j+1 < len(dst.Content); j += 2 {
+			if dst.Content[j].Value == k.Value {
+				idx = j
+				break
+			}
+		}
+
+		if idx < 0 {
+			dst.Content = append(dst.Content, k, v)
+			continue
+		}
+
+		var elem reflect.Type
+		switch {
+		case fields != nil:
+			if f, ok := fields[k.Value]; ok {
+				elem = f.Type
+			}
+		case t.Kind() == reflect.Map:
+			elem = t.Elem()
+		}
+
+		if elem != nil && isMergeable(elem) && dst.Content[idx+1].Kind == yaml.MappingNode && v.Kind == yaml.MappingNode {
+			mergeNode(dst.Content[idx+1], v, elem)
+			continue
+		}
+
+		dst.Content[idx], dst.Content[idx+1] = k, v
+	}
+}
+
+// isMergeable reports whether values of the type are merged with the
+// values of other files instead of being replaced
+func isMergeable(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == dynamicAttributesType, t == reflect.TypeFor[DynamicElement]():
+		return false
+	case t.Kind() == reflect.Map:
+		return true
+	case t.Kind() == reflect.Struct:
+		return t.PkgPath() != "time"
+	default:
+		return false
+	}
+}
+
+// resolveInclude returns the files matching the include relative to the
+// directory of the including file. Globs may match no file, plain paths
+// must exist.
+func resolveInclude(dir, pattern string) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+	}
+
+	if len(files) == 0 && !strings.ContainsAny(pattern, "*?[") {
+		return nil, fmt.Errorf("included file %q not found", pattern)
+	}
+
+	return files, nil
+}
+
+// takeIncludes removes the include list from the mapping and returns it
+func takeIncludes(root *yaml.Node) ([]string, error) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != includeKey {
+			continue
+		}
+
+		var includes []string
+		if err := root.Content[i+1].Decode(&includes); err != nil {
+			return nil, fmt.Errorf("line %d: include must be a list of files: %w", root.Content[i+1].Line, err)
+		}
+
+		root.Content = slices.Delete(root.Content, i, i+2) //revive:disable-line:add-constant // key and value
+		return includes, nil
+	}
+
+	return nil, nil
+}
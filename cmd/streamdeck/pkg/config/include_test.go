@@ -0,0 +1,177 @@
+package config
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(path.Dir(path.Join(dir, name)), 0o700))
+		require.NoError(t, os.WriteFile(path.Join(dir, name), []byte(content), 0o600))
+	}
+
+	return dir
+}
+
+func TestLoadIncludes(t *testing.T) {
+	t.Parallel()
+
+	dir := writeTestFiles(t, map[string]string{
+		"config.yaml": `---
+include:
+  - base.yaml
+  - pages.d/*.yaml
+display_off_time: 5m
+pages:
+  main:
+    keys:
+      1:
+        display:
+          type: text
+`,
+		"base.yaml": `---
+default_page: main
+display_off_time: 1m
+long_press_duration: 1s
+`,
+		"pages.d/a.yaml": `---
+include: [../shared/*.yaml]
+pages:
+  main:
+    overlay: menu
+    keys:
+      0:
+        display:
+          type: color
+          attributes:
+            color: red
+      1:
+        display:
+          type: image
+          attributes:
+            path: a.png
+`,
+		"pages.d/b.yaml": `---
+pages:
+  main:
+    keys:
+      0:
+        display:
+          type: color
+          attributes:
+            rgba: [0, 0, 255, 255]
+`,
+		"shared/menu.yaml": `---
+pages:
+  menu: {}
+`,
+	})
+
+	f, err := Load(path.Join(dir, "config.yaml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "main", f.DefaultPage)
+	assert.Equal(t, 5*time.Minute, f.DisplayOffTime)
+	assert.Equal(t, time.Second, f.LongPressDuration)
+	assert.Contains(t, f.Pages, "menu")
+
+	main := f.Pages["main"]
+	assert.Equal(t, "menu", main.Overlay)
+
+	// Elements are replaced as a whole by later files
+	attrs, err := DecodeAttributes[map[string]any](main.Keys[0].Display.Attributes)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"rgba": []any{0, 0, 255, 255}}, attrs)
+
+	assert.Equal(t, "text", main.Keys[1].Display.Type)
+	assert.Zero(t, main.Keys[1].Display.Attributes.Kind)
+
+	assert.Equal(t, []string{
+		path.Join(dir, "config.yaml"),
+		path.Join(dir, "base.yaml"),
+		path.Join(dir, "pages.d/a.yaml"),
+		path.Join(dir, "pages.d/../shared/menu.yaml"),
+		path.Join(dir, "pages.d/b.yaml"),
+	}, f.Sources())
+}
+
+func TestLoadIncludesExpandEnv(t *testing.T) {
+	t.Setenv("STREAMDECK_TEST_PAGE", "from-env")
+
+	dir := writeTestFiles(t, map[string]string{
+		"config.yaml":   "include: [\"${env.STREAMDECK_TEST_PAGE}.yaml\"]\n",
+		"from-env.yaml": "default_page: ${env.STREAMDECK_TEST_PAGE}\n",
+	})
+
+	f, err := Load(path.Join(dir, "config.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", f.DefaultPage)
+}
+
+func TestLoadIncludeErrors(t *testing.T) {
+	t.Parallel()
+
+	for name, files := range map[string]map[string]string{
+		"cycle": {
+			"config.yaml": "include: [a.yaml]\n",
+			"a.yaml":      "include: [config.yaml]\n",
+		},
+		"missing": {
+			"config.yaml": "include: [a.yaml]\n",
+		},
+		"no list": {
+			"config.yaml": "include: {a: b}\n",
+		},
+		"no mapping": {
+			"config.yaml": "include: [a.yaml]\n",
+			"a.yaml":      "[1, 2]\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Load(path.Join(writeTestFiles(t, files), "config.yaml"))
+			assert.Error(t, err)
+		})
+	}
+
+	// Globs may match no files
+	_, err := Load(path.Join(writeTestFiles(t, map[string]string{
+		"config.yaml": "include: [pages.d/*.yaml]\n",
+	}), "config.yaml"))
+	assert.NoError(t, err)
+}
+
+func TestValidateIncludes(t *testing.T) {
+	t.Parallel()
+
+	dir := writeTestFiles(t, map[string]string{
+		"config.yaml": "include: [pages.yaml]\ndefault_page: main\nunknown: true\n",
+		"pages.yaml":  "pages:\n  main:\n    overlay: missing\n",
+	})
+
+	problems, err := Validate(path.Join(dir, "config.yaml"), nil, func(ElementRef) []Problem { return nil })
+	require.NoError(t, err)
+
+	assert.Equal(t, []Problem{
+		{Position: Position{path.Join(dir, "config.yaml"), 3, 1}, Message: `unknown field "unknown"`},
+	}, problems)
+
+	require.NoError(t, os.WriteFile(path.Join(dir, "config.yaml"), []byte("include: [pages.yaml]\ndefault_page: main\n"), 0o600))
+
+	problems, err = Validate(path.Join(dir, "config.yaml"), nil, func(ElementRef) []Problem { return nil })
+	require.NoError(t, err)
+
+	assert.Equal(t, []Problem{
+		{Position: Position{path.Join(dir, "pages.yaml"), 3, 14}, Message: `overlay "missing" is not defined`},
+	}, problems)
+}
@@ -1,5 +1,15 @@
 package config
 
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// minChordKeys is the number of keys required for a chord, single
+// keys are handled by the key definitions
+const minChordKeys = 2
+
 // GetKeyDefinitions returns the effective key map including underlay and overlay pages.
 func (p Page) GetKeyDefinitions(cfg File) map[int]KeyDefinition {
 	var (
@@ -23,7 +33,7 @@ func (p Page) GetKeyDefinitions(cfg File) map[int]KeyDefinition {
 	// Assemble combination of keys
 	for _, pageDef := range defMaps {
 		for idx, kd := range pageDef {
-			if kd.Display.Type == "" {
+			if !kd.IsDefined() {
 				continue
 			}
 
@@ -33,3 +43,184 @@ func (p Page) GetKeyDefinitions(cfg File) map[int]KeyDefinition {
 
 	return result
 }
+
+// GetDialDefinitions returns the effective dial map including underlay and overlay pages.
+func (p Page) GetDialDefinitions(cfg File) map[int]DialDefinition {
+	result := make(map[int]DialDefinition)
+
+	for _, pageDef := range []map[int]DialDefinition{
+		cfg.Pages[p.Underlay].Dials,
+		p.Dials,
+		cfg.Pages[p.Overlay].Dials,
+	} {
+		for idx, dd := range pageDef {
+			if !dd.IsDefined() {
+				continue
+			}
+
+			result[idx] = dd
+		}
+	}
+
+	return result
+}
+
+// GetChords returns the effective chords including underlay and
+// overlay pages. Chords of the overlay take precedence over chords of
+// the page which take precedence over the underlay for the same keys.
+func (p Page) GetChords(cfg File) (result []ChordDefinition) {
+	seen := make(map[string]bool)
+
+	for _, chords := range [][]ChordDefinition{
+		cfg.Pages[p.Overlay].Chords,
+		p.Chords,
+		cfg.Pages[p.Underlay].Chords,
+	} {
+		for _, c := range chords {
+			id := fmt.Sprint(c.sortedKeys())
+			if len(c.Keys) < minChordKeys || seen[id] {
+				continue
+			}
+
+			seen[id] = true
+			result = append(result, c)
+		}
+	}
+
+	return result
+}
+
+// Matches reports whether exactly the keys of the chord are held
+func (c ChordDefinition) Matches(held []int) bool {
+	h := slices.Clone(held)
+	slices.Sort(h)
+
+	return slices.Equal(c.sortedKeys(), slices.Compact(h))
+}
+
+func (c ChordDefinition) sortedKeys() []int {
+	keys := slices.Clone(c.Keys)
+	slices.Sort(keys)
+
+	return slices.Compact(keys)
+}
+
+// GetPanel returns the effective panel wallpaper, the overlay takes
+// precedence over the page which takes precedence over the underlay.
+func (p Page) GetPanel(cfg File) PanelDefinition {
+	for _, pd := range []PanelDefinition{
+		cfg.Pages[p.Overlay].Panel,
+		p.Panel,
+		cfg.Pages[p.Underlay].Panel,
+	} {
+		if pd.IsDefined() {
+			return pd
+		}
+	}
+
+	return PanelDefinition{}
+}
+
+// GetInfoBar returns the effective info bar display, the overlay takes
+// precedence over the page which takes precedence over the underlay.
+func (p Page) GetInfoBar(cfg File) DynamicElement {
+	for _, ib := range []DynamicElement{
+		cfg.Pages[p.Overlay].InfoBar,
+		p.InfoBar,
+		cfg.Pages[p.Underlay].InfoBar,
+	} {
+		if ib.Type != "" {
+			return ib
+		}
+	}
+
+	return DynamicElement{}
+}
+
+// GetTouchStrip returns the effective touch strip definition, the
+// overlay takes precedence over the page which takes precedence over
+// the underlay.
+func (p Page) GetTouchStrip(cfg File) TouchStripDefinition {
+	for _, ts := range []TouchStripDefinition{
+		cfg.Pages[p.Overlay].TouchStrip,
+		p.TouchStrip,
+		cfg.Pages[p.Underlay].TouchStrip,
+	} {
+		if ts.IsDefined() {
+			return ts
+		}
+	}
+
+	return TouchStripDefinition{}
+}
+
+// IsDefined reports whether the dial has a display or any action.
+func (d DialDefinition) IsDefined() bool {
+	return d.Display.Type != "" || len(d.Actions) > 0 || len(d.RotateLeft) > 0 || len(d.RotateRight) > 0
+}
+
+// IsDefined reports whether the key has a display, any action or
+// states, keys without display are used on devices without display
+// (like pedals).
+func (k KeyDefinition) IsDefined() bool {
+	return k.Display.Type != "" || len(k.Actions) > 0 || len(k.States) > 0
+}
+
+// IsDefined reports whether the touch strip has a display or any action.
+func (t TouchStripDefinition) IsDefined() bool {
+	return t.Display.Type != "" || len(t.Actions) > 0 || len(t.SwipeLeft) > 0 || len(t.SwipeRight) > 0
+}
+
+// IsDefined reports whether the panel has a wallpaper image.
+func (p PanelDefinition) IsDefined() bool { return p.Path != "" || p.URL != "" }
+
+// ForState returns the key as shown in the state with the name: the
+// display of the state replaces the display of the key and its actions
+// follow the actions of the key. Unknown states show the first state.
+func (k KeyDefinition) ForState(name string) KeyDefinition {
+	if len(k.States) == 0 {
+		return k
+	}
+
+	idx, _ := k.StateIndex(name)
+	state := k.States[idx]
+
+	if state.Display.Type != "" {
+		k.Display = state.Display
+	}
+	k.Actions = append(slices.Clone(k.Actions), state.Actions...)
+
+	return k
+}
+
+// NextState returns the name of the state following the state with the
+// name, the last state is followed by the first one
+func (k KeyDefinition) NextState(name string) string {
+	if len(k.States) == 0 {
+		return ""
+	}
+
+	idx, _ := k.StateIndex(name)
+	return k.StateName((idx + 1) % len(k.States))
+}
+
+// StateIndex returns the index of the state with the name and whether
+// there is a state with that name, unknown names select the first state
+func (k KeyDefinition) StateIndex(name string) (int, bool) {
+	for i := range k.States {
+		if k.StateName(i) == name {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// StateName returns the name of the state with the index
+func (k KeyDefinition) StateName(idx int) string {
+	if name := k.States[idx].Name; name != "" {
+		return name
+	}
+
+	return strconv.Itoa(idx)
+}
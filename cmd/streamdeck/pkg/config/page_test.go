@@ -0,0 +1,156 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDialDefinitionsLayering(t *testing.T) {
+	t.Parallel()
+
+	var (
+		under = DialDefinition{Actions: []DynamicElement{{Type: "under"}}}
+		own   = DialDefinition{RotateLeft: []DynamicElement{{Type: "own"}}}
+		over  = DialDefinition{Display: DynamicElement{Type: "over"}}
+	)
+
+	cfg := File{Pages: map[string]Page{
+		"under": {Dials: map[int]DialDefinition{0: under, 1: under, 2: under}},
+		"over":  {Dials: map[int]DialDefinition{2: over, 3: {}}},
+	}}
+
+	page := Page{
+		Dials:    map[int]DialDefinition{1: own, 2: own},
+		Overlay:  "over",
+		Underlay: "under",
+	}
+
+	assert.Equal(t, map[int]DialDefinition{0: under, 1: own, 2: over}, page.GetDialDefinitions(cfg))
+}
+
+func TestGetChords(t *testing.T) {
+	t.Parallel()
+
+	var (
+		under = ChordDefinition{Keys: []int{0, 7}, Actions: []DynamicElement{{Type: "under"}}}
+		other = ChordDefinition{Keys: []int{1, 2}, Actions: []DynamicElement{{Type: "other"}}}
+		over  = ChordDefinition{Keys: []int{7, 0}, Actions: []DynamicElement{{Type: "over"}}}
+	)
+
+	cfg := File{Pages: map[string]Page{
+		"under": {Chords: []ChordDefinition{under, other}},
+		"over":  {Chords: []ChordDefinition{over}},
+	}}
+
+	page := Page{
+		Chords:   []ChordDefinition{{Keys: []int{3}}},
+		Overlay:  "over",
+		Underlay: "under",
+	}
+
+	assert.Equal(t, []ChordDefinition{over, other}, page.GetChords(cfg))
+}
+
+func TestChordMatches(t *testing.T) {
+	t.Parallel()
+
+	c := ChordDefinition{Keys: []int{7, 0}}
+
+	assert.True(t, c.Matches([]int{0, 7}))
+	assert.True(t, c.Matches([]int{7, 0}))
+	assert.False(t, c.Matches([]int{0}))
+	assert.False(t, c.Matches([]int{0, 3, 7}))
+	assert.False(t, c.Matches(nil))
+}
+
+func TestGetInfoBarPrecedence(t *testing.T) {
+	t.Parallel()
+
+	var (
+		under = DynamicElement{Type: "under"}
+		own   = DynamicElement{Type: "own"}
+		over  = DynamicElement{Type: "over"}
+	)
+
+	cfg := File{Pages: map[string]Page{
+		"under": {InfoBar: under},
+		"over":  {InfoBar: over},
+	}}
+
+	assert.Equal(t, over, Page{InfoBar: own, Overlay: "over", Underlay: "under"}.GetInfoBar(cfg))
+	assert.Equal(t, own, Page{InfoBar: own, Underlay: "under"}.GetInfoBar(cfg))
+	assert.Equal(t, under, Page{Underlay: "under"}.GetInfoBar(cfg))
+	assert.Equal(t, DynamicElement{}, Page{}.GetInfoBar(cfg))
+}
+
+func TestGetTouchStripPrecedence(t *testing.T) {
+	t.Parallel()
+
+	var (
+		under = TouchStripDefinition{Actions: []DynamicElement{{Type: "under"}}}
+		own   = TouchStripDefinition{SwipeLeft: []DynamicElement{{Type: "own"}}}
+		over  = TouchStripDefinition{Display: DynamicElement{Type: "over"}}
+	)
+
+	cfg := File{Pages: map[string]Page{
+		"under": {TouchStrip: under},
+		"over":  {TouchStrip: over},
+	}}
+
+	assert.Equal(t, over, Page{TouchStrip: own, Overlay: "over", Underlay: "under"}.GetTouchStrip(cfg))
+	assert.Equal(t, own, Page{TouchStrip: own, Underlay: "under"}.GetTouchStrip(cfg))
+	assert.Equal(t, under, Page{Underlay: "under"}.GetTouchStrip(cfg))
+	assert.Equal(t, TouchStripDefinition{}, Page{}.GetTouchStrip(cfg))
+}
+
+func TestGetPanelPrecedence(t *testing.T) {
+	t.Parallel()
+
+	var (
+		under = PanelDefinition{Path: "under.png"}
+		own   = PanelDefinition{URL: "https://example.com/own.gif", FrameRate: 5}
+		over  = PanelDefinition{Path: "over.jpg", Gap: 12}
+	)
+
+	cfg := File{Pages: map[string]Page{
+		"under": {Panel: under},
+		"over":  {Panel: over},
+	}}
+
+	assert.Equal(t, over, Page{Panel: own, Overlay: "over", Underlay: "under"}.GetPanel(cfg))
+	assert.Equal(t, own, Page{Panel: own, Underlay: "under"}.GetPanel(cfg))
+	assert.Equal(t, under, Page{Panel: PanelDefinition{Gap: 4}, Underlay: "under"}.GetPanel(cfg))
+	assert.False(t, Page{}.GetPanel(cfg).IsDefined())
+}
+
+func TestKeyDefinitionStates(t *testing.T) {
+	t.Parallel()
+
+	kd := KeyDefinition{
+		Display: DynamicElement{Type: "text"},
+		Actions: []DynamicElement{{Type: "common"}},
+		States: []KeyState{
+			{Name: "muted", Display: DynamicElement{Type: "image"}, Actions: []DynamicElement{{Type: "unmute"}}},
+			{Actions: []DynamicElement{{Type: "mute"}}},
+		},
+	}
+
+	assert.Equal(t, "1", kd.NextState("muted"))
+	assert.Equal(t, "muted", kd.NextState("1"))
+	assert.Equal(t, "1", kd.NextState("unknown"))
+
+	muted := kd.ForState("")
+	assert.Equal(t, "image", muted.Display.Type)
+	assert.Equal(t, []DynamicElement{{Type: "common"}, {Type: "unmute"}}, muted.Actions)
+
+	unmuted := kd.ForState("1")
+	assert.Equal(t, "text", unmuted.Display.Type)
+	assert.Equal(t, []DynamicElement{{Type: "common"}, {Type: "mute"}}, unmuted.Actions)
+
+	// The actions of the key are not changed
+	assert.Equal(t, []DynamicElement{{Type: "common"}}, kd.Actions)
+
+	plain := KeyDefinition{Display: DynamicElement{Type: "text"}}
+	assert.Equal(t, plain, plain.ForState("any"))
+}
@@ -1,9 +1,14 @@
 package config
 
 import (
+	"image"
+
 	"github.com/Luzifer/streamdeck/v2"
 )
 
+// blankPageName is the system page shown while the display is off
+const blankPageName = "@@blank"
+
 func applySystemPages(deck *streamdeck.Client, conf *File) {
 	blankPage := Page{Keys: make(map[int]KeyDefinition)}
 
@@ -20,20 +25,37 @@ func applySystemPages(deck *streamdeck.Client, conf *File) {
 		Relative: 1,
 	})
 
-	for i := 0; i < deck.NumKeys(); i++ {
-		blankPage.Keys[i] = KeyDefinition{
-			Display: DynamicElement{
+	returnAction := []DynamicElement{
+		{
+			Type:       "page",
+			Attributes: actionConf,
+		},
+	}
+
+	// Touch keys follow the regular keys and are blanked the same way,
+	// devices without display only get the return action
+	for i := 0; i < deck.NumKeys()+deck.NumTouchKeys(); i++ {
+		kd := KeyDefinition{Actions: returnAction}
+		if deck.HasDisplay() {
+			kd.Display = DynamicElement{
 				Type:       "color",
 				Attributes: displayConf,
-			},
-			Actions: []DynamicElement{
-				{
-					Type:       "page",
-					Attributes: actionConf,
-				},
-			},
+			}
 		}
+
+		blankPage.Keys[i] = kd
+	}
+
+	// Pressing a dial or tapping the touch strip also returns from
+	// the blank page
+	blankPage.Dials = make(map[int]DialDefinition)
+	for i := 0; i < deck.NumDials(); i++ {
+		blankPage.Dials[i] = DialDefinition{Actions: returnAction}
+	}
+
+	if deck.LCDSize() != (image.Point{}) {
+		blankPage.TouchStrip = TouchStripDefinition{Actions: returnAction}
 	}
 
-	conf.Pages["@@blank"] = blankPage
+	conf.Pages[blankPageName] = blankPage
 }
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/secrets"
+	"go.yaml.in/yaml/v3"
+)
+
+// templatesKey is the key of the templates section of the file
+const templatesKey = "templates"
+
+var (
+	keyDefinitionType = reflect.TypeFor[KeyDefinition]()
+	paramPattern      = regexp.MustCompile(`\$\{param\.([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// templateUse is the template value of a key the node was expanded for
+type templateUse struct {
+	name string
+	node *yaml.Node
+}
+
+// expandTemplates replaces the keys using a template by a copy of the
+// template with the parameters of the key substituted and the display
+// and actions of the key merged on top. Problems found while expanding
+// are collected in the loader.
+func (l *loader) expandTemplates(root *yaml.Node) {
+	templates := MappingValue(root, templatesKey)
+
+	var keys []*yaml.Node
+	// Structure problems are reported when decoding the file
+	checkNode(nil, nil, root, reflect.TypeFor[File](), func(path []string, _, node *yaml.Node, t reflect.Type) {
+		if t == keyDefinitionType && path[0] != templatesKey && node.Kind == yaml.MappingNode {
+			keys = append(keys, node)
+		}
+	})
+
+	for _, key := range keys {
+		nameNode := MappingValue(key, "template")
+		if nameNode == nil || nameNode.Value == "" {
+			continue
+		}
+
+		tmpl := MappingValue(templates, nameNode.Value)
+		if tmpl == nil {
+			l.problems = append(l.problems, NewProblem(nameNode, "template %q is not defined", nameNode.Value))
+			continue
+		}
+
+		if tmpl.Kind != yaml.MappingNode {
+			continue
+		}
+
+		var params map[string]string
+		if paramsNode := MappingValue(key, "params"); paramsNode != nil {
+			if err := paramsNode.Decode(&params); err != nil {
+				// Reported when decoding the file
+				continue
+			}
+		}
+
+		expanded := l.copyTemplate(tmpl, params, templateUse{name: nameNode.Value, node: nameNode})
+		mergeNode(expanded, key, keyDefinitionType)
+
+		expanded.Line, expanded.Column = key.Line, key.Column
+		*key = *expanded
+	}
+}
+
+// copyTemplate copies the node tree of the template substituting the
+// parameters in all strings. Plain scalars have their type resolved
+// again after substituting to allow parameters for numbers and flags.
+func (l *loader) copyTemplate(node *yaml.Node, params map[string]string, use templateUse) *yaml.Node {
+	c := *node
+	c.Content = make([]*yaml.Node, len(node.Content))
+
+	l.origin[&c] = l.origin[node]
+	l.expansions[&c] = use
+
+	for i, child := range node.Content {
+		c.Content[i] = l.copyTemplate(child, params, use)
+	}
+
+	if c.Kind != yaml.ScalarNode || c.Tag != "!!str" || !paramPattern.MatchString(c.Value) {
+		return &c
+	}
+
+	c.Value = paramPattern.ReplaceAllStringFunc(c.Value, func(match string) string {
+		name := paramPattern.FindStringSubmatch(match)[1]
+
+		value, ok := params[name]
+		if !ok {
+			l.problems = append(l.problems, NewProblem(&c, "parameter %q is not set", name))
+			return match
+		}
+
+		return value
+	})
+
+	if c.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+		c.Tag = ""
+		c.Tag = c.ShortTag()
+	}
+
+	return &c
+}
+
+// locate sets the file of the problem, notes the key a template was
+// expanded for when the problem is located within a template and
+// redacts secrets from the message
+func (l *loader) locate(p Problem) Problem {
+	p.File = l.origin[p.node]
+
+	if use, ok := l.expansions[p.node]; ok {
+		p.Message = fmt.Sprintf("%s (template %q used at %s:%d:%d)", p.Message, use.name, l.origin[use.node], use.node.Line, use.node.Column)
+	}
+
+	// Values might have been resolved from secrets
+	p.Message = secrets.Redact(p.Message)
+
+	// The node is only kept to find the file it was read from
+	p.node = nil
+
+	return p
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTemplates(t *testing.T) {
+	t.Parallel()
+
+	filename := writeTestConfig(t, `---
+templates:
+  stat:
+    display:
+      type: exec
+      attributes:
+        command: [cat, "${param.file}"]
+        font_size: ${param.size}
+        caption: "${param.size}"
+        interval: 5s
+    actions:
+      - type: page
+        attributes:
+          name: ${param.page}
+pages:
+  main:
+    keys:
+      0:
+        template: stat
+        params:
+          file: /proc/loadavg
+          page: load
+          size: 20
+      1:
+        template: stat
+        params:
+          file: /proc/uptime
+          page: uptime
+          size: 24
+        actions:
+          - type: reload_config
+  load: {}
+  uptime: {}
+`)
+
+	f, err := Load(filename)
+	require.NoError(t, err)
+
+	type execAttrs struct {
+		Caption  string        `yaml:"caption"`
+		Command  []string      `yaml:"command"`
+		FontSize float64       `yaml:"font_size"`
+		Interval time.Duration `yaml:"interval"`
+	}
+
+	key := f.Pages["main"].Keys[0]
+	assert.Equal(t, "stat", key.Template)
+	assert.Equal(t, "exec", key.Display.Type)
+
+	attrs, err := DecodeAttributes[execAttrs](key.Display.Attributes)
+	require.NoError(t, err)
+	assert.Equal(t, execAttrs{
+		Caption:  "20",
+		Command:  []string{"cat", "/proc/loadavg"},
+		FontSize: 20,
+		Interval: 5 * time.Second,
+	}, attrs)
+
+	require.Len(t, key.Actions, 1)
+	action, err := DecodeAttributes[map[string]string](key.Actions[0].Attributes)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "load"}, action)
+
+	// Actions of the key replace those of the template
+	key = f.Pages["main"].Keys[1]
+	attrs, err = DecodeAttributes[execAttrs](key.Display.Attributes)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cat", "/proc/uptime"}, attrs.Command)
+	assert.Equal(t, []DynamicElement{{Type: "reload_config"}}, key.Actions)
+
+	// Templates themselves are kept untouched
+	attrs2, err := DecodeAttributes[map[string]any](f.Templates["stat"].Display.Attributes)
+	require.NoError(t, err)
+	assert.Equal(t, "${param.size}", attrs2["font_size"])
+}
+
+func TestLoadTemplateErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := Load(writeTestConfig(t, "pages:\n  main:\n    keys:\n      0:\n        template: missing\n"))
+	assert.ErrorContains(t, err, `template "missing" is not defined`)
+
+	_, err = Load(writeTestConfig(t, "templates:\n  t:\n    display:\n      type: text\n      attributes:\n        text: ${param.text}\npages:\n  main:\n    keys:\n      0:\n        template: t\n"))
+	assert.ErrorContains(t, err, `parameter "text" is not set`)
+}
+
+func TestValidateTemplates(t *testing.T) {
+	t.Parallel()
+
+	filename := writeTestConfig(t, `---
+templates:
+  goto:
+    display:
+      type: text
+      attributes:
+        text: ${param.page}
+    actions:
+      - type: page
+        attributes:
+          name: ${param.page}
+pages:
+  main:
+    keys:
+      0:
+        template: goto
+        params:
+          page: other
+      1:
+        template: goto
+      2:
+        template: nope
+`)
+
+	problems, err := Validate(filename, nil, func(ElementRef) []Problem { return nil })
+	require.NoError(t, err)
+
+	assert.Equal(t, []Problem{
+		{Position: Position{filename, 7, 15}, Message: `parameter "page" is not set (template "goto" used at ` + filename + `:20:19)`},
+		{Position: Position{filename, 11, 17}, Message: `parameter "page" is not set (template "goto" used at ` + filename + `:20:19)`},
+		{Position: Position{filename, 22, 19}, Message: `template "nope" is not defined`},
+	}, problems)
+
+	// Pages referenced through parameters are checked per key
+	filename = writeTestConfig(t, `---
+templates:
+  goto:
+    actions:
+      - type: page
+        attributes:
+          name: ${param.page}
+pages:
+  main:
+    keys:
+      0:
+        template: goto
+        params:
+          page: other
+`)
+
+	var names []string
+	problems, err = Validate(filename, nil, func(el ElementRef) []Problem {
+		if el.Element.Type == "page" {
+			attrs, err := DecodeAttributes[map[string]string](el.Element.Attributes)
+			require.NoError(t, err)
+			names = append(names, attrs["name"])
+
+			return []Problem{NewProblem(MappingValue(MappingValue(el.Node, "attributes"), "name"), "page not found")}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"other"}, names)
+	assert.Equal(t, []Problem{
+		{Position: Position{filename, 7, 17}, Message: `page not found (template "goto" used at ` + filename + `:12:19)`},
+	}, problems)
+}
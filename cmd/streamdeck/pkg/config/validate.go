@@ -0,0 +1,346 @@
+package config
+
+import (
+	"cmp"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/Luzifer/streamdeck/v2"
+)
+
+type (
+	// ElementRef is an action or display element found while validating
+	// the configuration file
+	ElementRef struct {
+		Element DynamicElement
+		// Display is true for display elements, false for actions
+		Display bool
+		// Node is the mapping node of the element
+		Node *yaml.Node
+		// HasPage reports whether the page exists for the deck with the
+		// given serial, an empty serial uses the deck the element is
+		// defined for
+		HasPage func(serial, name string) bool
+
+		scope string
+	}
+
+	// pageRef is a reference to a page name from within the scope of
+	// a page (overlay and underlay) or a deck (default page)
+	pageRef struct {
+		node  *yaml.Node
+		field string
+		from  string
+		scope string
+	}
+
+	// validation collects the references while checking the node tree
+	validation struct {
+		elements []ElementRef
+		dials    []*yaml.Node
+		files    []*yaml.Node
+		keys     []*yaml.Node
+		pages    []pageRef
+		states   []*yaml.Node
+	}
+)
+
+// Validate reads the configuration file including the files it includes
+// and reports problems with the position of their value: the structure of the file, pages referenced
+// but not defined for the deck, cycles of overlays and underlays and
+// font and image files not existing. When a deck is given keys and
+// dials not present on its model are reported. Every action and
+// display element is passed to checkElement to validate its type and
+// attributes. Problems within keys expanded from a template are located
+// in the template and name the key using it.
+func Validate(confFile string, deck *streamdeck.Client, checkElement func(ElementRef) []Problem) ([]Problem, error) {
+	rawConf, l, err := loadTree(confFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var v validation
+	problems := checkNode(nil, nil, &rawConf, reflect.TypeFor[File](), v.visit)
+	if problems = append(problems, l.problems...); len(problems) > 0 {
+		// References of a broken structure can not be trusted
+		return l.sortProblems(problems), nil
+	}
+
+	f, err := Load(confFile)
+	if err != nil {
+		return nil, err
+	}
+
+	problems = v.checkPages(f)
+	problems = append(problems, v.checkFiles()...)
+	problems = append(problems, v.checkStates()...)
+
+	if deck != nil {
+		problems = append(problems, v.checkDeck(deck)...)
+	}
+
+	for _, el := range v.elements {
+		problems = append(problems, checkElement(el)...)
+	}
+
+	return l.sortProblems(problems), nil
+}
+
+// hasPage reports whether the page exists within the scope (the serial
+// of a deck or empty for global pages)
+func hasPage(f File, scope, name string) bool {
+	if name == blankPageName {
+		return true
+	}
+
+	if _, ok := f.Pages[name]; ok {
+		return true
+	}
+
+	_, ok := f.Decks[scope].Pages[name]
+	return ok
+}
+
+// pageScope splits the path of a value within a page into the serial
+// of the deck (empty for global pages), the page name and the path
+// within the page
+func pageScope(path []string) (scope, page string, rest []string, ok bool) {
+	switch {
+	case len(path) >= 2 && path[0] == "pages": //revive:disable-line:add-constant // path length
+		return "", path[1], path[2:], true
+	case len(path) >= 4 && path[0] == "decks" && path[2] == "pages": //revive:disable-line:add-constant // path length
+		return path[1], path[3], path[4:], true
+	default:
+		return "", "", nil, false
+	}
+}
+
+// sortProblems locates the problems and sorts them by the order the
+// files were read and their position within the file
+func (l *loader) sortProblems(problems []Problem) []Problem {
+	for i := range problems {
+		problems[i] = l.locate(problems[i])
+	}
+
+	slices.SortStableFunc(problems, func(a, b Problem) int {
+		return cmp.Or(
+			cmp.Compare(slices.Index(l.files, a.File), slices.Index(l.files, b.File)),
+			cmp.Compare(a.Line, b.Line),
+			cmp.Compare(a.Column, b.Column),
+		)
+	})
+
+	return problems
+}
+
+// checkDeck reports keys and dials not available on the deck
+func (v validation) checkDeck(deck *streamdeck.Client) (problems []Problem) {
+	name := streamdeck.DeckToName[deck.Model()]
+
+	for _, node := range v.keys {
+		if idx, err := strconv.Atoi(node.Value); err == nil && (idx < 0 || idx >= deck.NumKeys()+deck.NumTouchKeys()) {
+			problems = append(problems, NewProblem(node, "key %d does not exist on %s having %d keys", idx, name, deck.NumKeys()+deck.NumTouchKeys()))
+		}
+	}
+
+	for _, node := range v.dials {
+		if idx, err := strconv.Atoi(node.Value); err == nil && (idx < 0 || idx >= deck.NumDials()) {
+			problems = append(problems, NewProblem(node, "dial %d does not exist on %s having %d dials", idx, name, deck.NumDials()))
+		}
+	}
+
+	return problems
+}
+
+// checkFiles reports font and image files not existing
+func (v validation) checkFiles() (problems []Problem) {
+	for _, node := range v.files {
+		if node.Value == "" {
+			continue
+		}
+
+		if _, err := os.Stat(node.Value); err != nil {
+			problems = append(problems, NewProblem(node, "file %q not found", node.Value))
+		}
+	}
+
+	return problems
+}
+
+// checkStates reports states of keys sharing the same name and state
+// commands of keys without states
+func (v validation) checkStates() (problems []Problem) {
+	for _, node := range v.states {
+		var kd KeyDefinition
+		if err := node.Decode(&kd); err != nil {
+			// Structure was checked before
+			continue
+		}
+
+		if len(kd.States) == 0 {
+			if cmd := MappingValue(node, "state_command"); cmd != nil && len(kd.StateCommand.Command) > 0 {
+				problems = append(problems, NewProblem(cmd, "state command given for key without states"))
+			}
+			continue
+		}
+
+		seen := make(map[string]bool, len(kd.States))
+		for i := range kd.States {
+			name := kd.StateName(i)
+			if seen[name] {
+				problems = append(problems, NewProblem(MappingValue(node, "states").Content[i], "state %q is defined multiple times", name))
+			}
+			seen[name] = true
+		}
+	}
+
+	return problems
+}
+
+// checkPages reports references to pages not defined for the deck and
+// overlays and underlays referencing back to their page
+func (v *validation) checkPages(f File) (problems []Problem) {
+	hasPageIn := func(scope string) func(serial, name string) bool {
+		return func(serial, name string) bool {
+			if serial == "" {
+				serial = scope
+			}
+			return hasPage(f, serial, name)
+		}
+	}
+
+	for i := range v.elements {
+		v.elements[i].HasPage = hasPageIn(v.elements[i].scope)
+	}
+
+	for _, ref := range v.pages {
+		if ref.node.Value == "" {
+			continue
+		}
+
+		if !hasPage(f, ref.scope, ref.node.Value) {
+			problems = append(problems, NewProblem(ref.node, "%s %q is not defined", strings.ReplaceAll(ref.field, "_", " "), ref.node.Value))
+			continue
+		}
+
+		if ref.from == "" {
+			continue
+		}
+
+		if cycle := layerCycle(f, ref.scope, ref.from, ref.node.Value); cycle != nil {
+			problems = append(problems, NewProblem(ref.node, "%s %q creates a cycle: %s", ref.field, ref.node.Value, strings.Join(cycle, " -> ")))
+		}
+	}
+
+	return problems
+}
+
+// visit collects the references to check after the structure of the
+// file was found to be valid
+func (v *validation) visit(path []string, key, node *yaml.Node, t reflect.Type) {
+	if len(path) > 0 && path[0] == templatesKey {
+		// Templates are checked where they are used
+		return
+	}
+
+	if t == reflect.TypeFor[DynamicElement]() {
+		scope, _, _, _ := pageScope(path)
+		last := path[len(path)-1]
+		if _, err := strconv.Atoi(last); err == nil {
+			// Element within a list of actions
+			last = path[len(path)-2]
+		}
+
+		var el DynamicElement
+		if err := node.Decode(&el); err == nil {
+			v.elements = append(v.elements, ElementRef{
+				Element: el,
+				Display: last == "display" || last == "info_bar",
+				Node:    node,
+				scope:   scope,
+			})
+		}
+		return
+	}
+
+	switch {
+	case slices.Equal(path, []string{"caption_font"}), slices.Equal(path, []string{"render_font"}):
+		v.files = append(v.files, node)
+		return
+
+	case slices.Equal(path, []string{"default_page"}):
+		v.pages = append(v.pages, pageRef{node: node, field: "default_page"})
+		return
+
+	case len(path) == 3 && path[0] == "decks" && path[2] == "default_page": //revive:disable-line:add-constant // path length
+		v.pages = append(v.pages, pageRef{node: node, field: "default_page", scope: path[1]})
+		return
+	}
+
+	scope, page, rest, ok := pageScope(path)
+	if !ok || len(rest) == 0 {
+		return
+	}
+
+	switch {
+	case len(rest) == 1 && (rest[0] == "overlay" || rest[0] == "underlay"):
+		v.pages = append(v.pages, pageRef{node: node, field: rest[0], from: page, scope: scope})
+
+	case len(rest) == 2 && rest[0] == "keys": //revive:disable-line:add-constant // path length
+		v.keys = append(v.keys, key)
+		v.states = append(v.states, node)
+
+	case len(rest) == 4 && rest[0] == "chords" && rest[2] == "keys": //revive:disable-line:add-constant // path length
+		v.keys = append(v.keys, node)
+
+	case len(rest) == 2 && rest[0] == "dials": //revive:disable-line:add-constant // path length
+		v.dials = append(v.dials, key)
+
+	case slices.Equal(rest, []string{"panel", "path"}):
+		v.files = append(v.files, node)
+	}
+}
+
+// layerCycle returns the pages forming a cycle when the page uses the
+// given page as overlay or underlay or nil if there is no cycle
+func layerCycle(f File, scope, page, layer string) []string {
+	var (
+		seen = map[string]bool{page: true}
+		walk func(current string, trail []string) []string
+	)
+
+	walk = func(current string, trail []string) []string {
+		if current == page {
+			return trail
+		}
+
+		if seen[current] {
+			return nil
+		}
+		seen[current] = true
+
+		p, ok := f.Decks[scope].Pages[current]
+		if !ok {
+			p = f.Pages[current]
+		}
+
+		for _, next := range []string{p.Overlay, p.Underlay} {
+			if next == "" {
+				continue
+			}
+
+			if cycle := walk(next, append(slices.Clone(trail), next)); cycle != nil {
+				return cycle
+			}
+		}
+
+		return nil
+	}
+
+	return walk(layer, []string{page, layer})
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Luzifer/streamdeck/v2"
+)
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	filename := path.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+
+	return filename
+}
+
+func TestValidateStructure(t *testing.T) {
+	t.Parallel()
+
+	filename := writeTestConfig(t, `---
+default_page: main
+display_off_time: soon
+pages:
+  main:
+    keys:
+      abc: {}
+      1:
+        display: [1, 2]
+    unknown: true
+`)
+
+	problems, err := Validate(filename, nil, func(ElementRef) []Problem { return nil })
+	require.NoError(t, err)
+
+	assert.Equal(t, []Problem{
+		{Position: Position{filename, 3, 19}, Message: `invalid value "soon", expected duration`},
+		{Position: Position{filename, 7, 7}, Message: `invalid key "abc", expected integer`},
+		{Position: Position{filename, 9, 18}, Message: `expected mapping, found sequence`},
+		{Position: Position{filename, 10, 5}, Message: `unknown field "unknown"`},
+	}, problems)
+}
+
+func TestValidateReferences(t *testing.T) {
+	t.Parallel()
+
+	fake, err := streamdeck.NewFakeDeck(streamdeck.StreamDeckMini)
+	require.NoError(t, err)
+
+	client, err := streamdeck.NewWithTransport(streamdeck.StreamDeckMini, fake)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	filename := writeTestConfig(t, `---
+default_page: main
+decks:
+  AL01:
+    default_page: own
+    pages:
+      own:
+        underlay: main
+pages:
+  main:
+    underlay: base
+    panel:
+      path: /does/not/exist.png
+    keys:
+      6:
+        display:
+          type: color
+        actions:
+          - type: page
+  base:
+    overlay: main
+    dials:
+      0: {}
+`)
+
+	var elements []ElementRef
+	problems, err := Validate(filename, client, func(el ElementRef) []Problem {
+		elements = append(elements, el)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Problem{
+		{Position: Position{filename, 11, 15}, Message: `underlay "base" creates a cycle: main -> base -> main`},
+		{Position: Position{filename, 13, 13}, Message: `file "/does/not/exist.png" not found`},
+		{Position: Position{filename, 15, 7}, Message: `key 6 does not exist on StreamDeck Mini having 6 keys`},
+		{Position: Position{filename, 21, 14}, Message: `overlay "main" creates a cycle: base -> main -> base`},
+		{Position: Position{filename, 23, 7}, Message: `dial 0 does not exist on StreamDeck Mini having 0 dials`},
+	}, problems)
+
+	require.Len(t, elements, 2)
+
+	var display, action ElementRef
+	for _, el := range elements {
+		switch el.Element.Type {
+		case "color":
+			display = el
+		case "page":
+			action = el
+		}
+	}
+
+	assert.True(t, display.Display)
+	assert.Equal(t, 17, display.Node.Line)
+	assert.False(t, action.Display)
+
+	// Pages of the deck are only known within the deck scope
+	assert.True(t, action.HasPage("", "base"))
+	assert.True(t, action.HasPage("", "@@blank"))
+	assert.False(t, action.HasPage("", "own"))
+	assert.True(t, action.HasPage("AL01", "own"))
+}
+
+func TestValidateStates(t *testing.T) {
+	t.Parallel()
+
+	filename := writeTestConfig(t, `---
+pages:
+  main:
+    keys:
+      0:
+        states:
+          - name: "on"
+          - display:
+              type: color
+          - name: "on"
+      1:
+        state_command:
+          command: [date]
+`)
+
+	var elements []ElementRef
+	problems, err := Validate(filename, nil, func(el ElementRef) []Problem {
+		elements = append(elements, el)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Problem{
+		{Position: Position{filename, 10, 13}, Message: `state "on" is defined multiple times`},
+		{Position: Position{filename, 13, 11}, Message: `state command given for key without states`},
+	}, problems)
+
+	require.Len(t, elements, 1)
+	assert.True(t, elements[0].Display)
+}
+
+func TestValidateRedactsSecrets(t *testing.T) {
+	t.Parallel()
+
+	secret := path.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secret, []byte("validate-secret-1337\n"), 0o600))
+
+	filename := writeTestConfig(t, "display_off_time: ${file."+secret+"}\n")
+
+	problems, err := Validate(filename, nil, func(ElementRef) []Problem { return nil })
+	require.NoError(t, err)
+
+	assert.Equal(t, []Problem{
+		{Position: Position{filename, 1, 19}, Message: `invalid value "[redacted]", expected duration`},
+	}, problems)
+}
@@ -0,0 +1,176 @@
+// Package animation provides animated image display elements.
+package animation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+	"time"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/frames"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/helpers"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules/opts"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/renderer"
+	log "github.com/sirupsen/logrus"
+)
+
+type (
+	// Display plays an animated GIF, APNG or sprite sheet on a key.
+	Display struct{}
+
+	// Attrs contains configuration for the animation display.
+	Attrs struct {
+		Caption string       `json:"caption,omitempty" yaml:"caption,omitempty"`
+		Loops   *int         `json:"loops,omitempty" yaml:"loops,omitempty"`
+		Path    string       `json:"path,omitempty" yaml:"path,omitempty"`
+		Sprite  *SpriteAttrs `json:"sprite,omitempty" yaml:"sprite,omitempty"`
+		URL     string       `json:"url,omitempty" yaml:"url,omitempty"`
+	}
+
+	// SpriteAttrs configures how frames are cut from a sprite sheet.
+	// Frames default to squares of the sheet height (a horizontal
+	// strip), all frames fitting into the sheet are played.
+	SpriteAttrs struct {
+		FrameDelay  time.Duration `json:"frame_delay,omitempty" yaml:"frame_delay,omitempty"`
+		FrameHeight int           `json:"frame_height,omitempty" yaml:"frame_height,omitempty"`
+		FrameWidth  int           `json:"frame_width,omitempty" yaml:"frame_width,omitempty"`
+		Frames      int           `json:"frames,omitempty" yaml:"frames,omitempty"`
+	}
+)
+
+// Display renders the first frame of the animation on the selected key.
+func (d Display) Display(ctx context.Context, idx int, devs opts.Runtime, atts config.DynamicAttributes) error {
+	anim, attributes, err := d.load(ctx, devs, atts)
+	if err != nil {
+		return err
+	}
+
+	// Only the first frame is shown, skip rendering the others
+	frame, err := d.renderFrame(devs, attributes, anim.Frames[0])
+	if err != nil {
+		return err
+	}
+
+	if err = ctx.Err(); err != nil {
+		// Page context was cancelled, do not draw
+		return fmt.Errorf("page context cancelled: %w", err)
+	}
+
+	if err = devs.Deck.FillImage(idx, frame); err != nil {
+		return fmt.Errorf("setting image: %w", err)
+	}
+
+	return nil
+}
+
+// NeedsLoop reports whether the display should be played. Animations
+// are always played, still images stop after showing their only frame.
+func (Display) NeedsLoop(atts config.DynamicAttributes) bool {
+	_, err := config.DecodeAttributes[Attrs](atts)
+	return err == nil
+}
+
+// StartLoopDisplay loads the animation and plays it in sync with all
+// other animations until it finished or the context is cancelled.
+func (d Display) StartLoopDisplay(ctx context.Context, idx int, devs opts.Runtime, atts config.DynamicAttributes) error {
+	anim, attributes, err := d.load(ctx, devs, atts)
+	if err != nil {
+		return err
+	}
+
+	keyFrames := make([]image.Image, 0, len(anim.Frames))
+	for _, frame := range anim.Frames {
+		keyFrame, err := d.renderFrame(devs, attributes, frame)
+		if err != nil {
+			return err
+		}
+
+		keyFrames = append(keyFrames, keyFrame)
+	}
+
+	go func() {
+		err := anim.Play(ctx, frames.DefaultClock, func(frame int) error {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("page context cancelled: %w", err)
+			}
+
+			return devs.Deck.FillImage(idx, keyFrames[frame]) //nolint:wrapcheck // logged below
+		})
+		if err != nil && !errors.Is(ctx.Err(), context.Canceled) {
+			log.WithError(err).WithField("key", idx).Error("playing animation")
+		}
+	}()
+
+	return nil
+}
+
+// load decodes the animation, cuts the frames of sprite sheets and
+// applies the number of loops
+func (Display) load(ctx context.Context, devs opts.Runtime, atts config.DynamicAttributes) (frames.Animation, Attrs, error) {
+	attributes, err := opts.DecodeAttributes[Attrs](devs, atts)
+	if err != nil {
+		return frames.Animation{}, attributes, fmt.Errorf("decoding attributes: %w", err)
+	}
+
+	filename := attributes.Path
+	switch {
+	case filename != "":
+		// User supplied a path, rely on that
+
+	case attributes.URL != "":
+		if filename, err = helpers.CachedDownload(ctx, attributes.URL); err != nil {
+			return frames.Animation{}, attributes, fmt.Errorf("downloading animation url: %w", err)
+		}
+
+	default:
+		return frames.Animation{}, attributes, fmt.Errorf("no path or url attribute specified")
+	}
+
+	anim, err := frames.Load(filename)
+	if err != nil {
+		return frames.Animation{}, attributes, fmt.Errorf("loading animation: %w", err)
+	}
+
+	if len(anim.Frames) == 0 {
+		return frames.Animation{}, attributes, fmt.Errorf("animation has no frames")
+	}
+
+	if sprite := attributes.Sprite; sprite != nil {
+		bounds := anim.Frames[0].Bounds()
+
+		size := image.Pt(sprite.FrameWidth, sprite.FrameHeight)
+		if size.Y <= 0 {
+			size.Y = bounds.Dy()
+		}
+		if size.X <= 0 {
+			size.X = size.Y
+		}
+
+		if anim, err = frames.SpriteSheet(anim.Frames[0], size, sprite.Frames, sprite.FrameDelay); err != nil {
+			return frames.Animation{}, attributes, fmt.Errorf("cutting sprite sheet: %w", err)
+		}
+	}
+
+	if attributes.Loops != nil {
+		anim.Plays = max(*attributes.Loops, 0)
+	}
+
+	return anim, attributes, nil
+}
+
+// renderFrame renders the frame for the key with the caption on top
+func (Display) renderFrame(devs opts.Runtime, attributes Attrs, frame image.Image) (image.Image, error) {
+	imgRenderer := renderer.NewTextOnImageRenderer(devs)
+	imgRenderer.DrawImage(frame)
+
+	if caption := strings.TrimSpace(attributes.Caption); caption != "" {
+		if err := imgRenderer.DrawCaptionText(caption); err != nil {
+			return nil, fmt.Errorf("rendering caption: %w", err)
+		}
+	}
+
+	return imgRenderer.GetImage(), nil
+}
@@ -24,7 +24,7 @@ type (
 
 // Display renders the configured color on the selected key.
 func (d Display) Display(ctx context.Context, idx int, devs opts.Runtime, atts config.DynamicAttributes) (err error) {
-	attributes, err := config.DecodeAttributes[Attrs](atts)
+	attributes, err := opts.DecodeAttributes[Attrs](devs, atts)
 	if err != nil {
 		return fmt.Errorf("decoding attributes: %w", err)
 	}
@@ -39,7 +39,7 @@ type (
 
 // Display executes the command and renders its output as a text display.
 func (Display) Display(ctx context.Context, idx int, devs opts.Runtime, atts config.DynamicAttributes) (err error) {
-	attributes, err := config.DecodeAttributes[Attrs](atts)
+	attributes, err := opts.DecodeAttributes[Attrs](devs, atts)
 	if err != nil {
 		return fmt.Errorf("decoding attributes: %w", err)
 	}
@@ -106,7 +106,7 @@ func (Display) NeedsLoop(atts config.DynamicAttributes) bool {
 
 // StartLoopDisplay starts periodic display refresh until the context is cancelled.
 func (d *Display) StartLoopDisplay(ctx context.Context, idx int, devs opts.Runtime, atts config.DynamicAttributes) error {
-	attributes, err := config.DecodeAttributes[Attrs](atts)
+	attributes, err := opts.DecodeAttributes[Attrs](devs, atts)
 	if err != nil {
 		return fmt.Errorf("decoding attributes: %w", err)
 	}
@@ -3,22 +3,15 @@ package image
 
 import (
 	"context"
-	"crypto/sha256"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"path"
 	"strings"
 
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/helpers"
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules/opts"
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/renderer"
-	"github.com/sirupsen/logrus"
 )
 
-const cacheDirMode = 0o700
-
 type (
 	// Display renders an image on a key.
 	Display struct{}
@@ -33,7 +26,7 @@ type (
 
 // Display renders the configured image on the selected key.
 func (d Display) Display(ctx context.Context, idx int, devs opts.Runtime, atts config.DynamicAttributes) error {
-	attributes, err := config.DecodeAttributes[Attrs](atts)
+	attributes, err := opts.DecodeAttributes[Attrs](devs, atts)
 	if err != nil {
 		return fmt.Errorf("decoding attributes: %w", err)
 	}
@@ -67,21 +60,7 @@ func (d Display) Display(ctx context.Context, idx int, devs opts.Runtime, atts c
 	return nil
 }
 
-func (Display) getCacheFileName(url string) (string, error) {
-	ucd, err := os.UserCacheDir()
-	if err != nil {
-		return "", fmt.Errorf("getting user cache dir: %w", err)
-	}
-
-	cacheDir := path.Join(ucd, "io.luzifer.streamdeck")
-	if err = os.MkdirAll(cacheDir, cacheDirMode); err != nil {
-		return "", fmt.Errorf("creating cache dir: %w", err)
-	}
-
-	return path.Join(cacheDir, fmt.Sprintf("%x", sha256.Sum256([]byte(url)))), nil
-}
-
-func (d Display) getRenderImageFileName(ctx context.Context, attributes Attrs) (filename string, err error) {
+func (Display) getRenderImageFileName(ctx context.Context, attributes Attrs) (filename string, err error) {
 	if attributes.Path != "" {
 		// User supplied a path, rely on that
 		return attributes.Path, nil
@@ -92,40 +71,8 @@ func (d Display) getRenderImageFileName(ctx context.Context, attributes Attrs) (
 		return "", fmt.Errorf("no path or url attribute specified")
 	}
 
-	filename, err = d.getCacheFileName(attributes.URL)
-	if err != nil {
-		return "", fmt.Errorf("getting cache filename for image url: %w", err)
-	}
-
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, attributes.URL, nil)
-		if err != nil {
-			return "", fmt.Errorf("creating image request: %w", err)
-		}
-
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return "", fmt.Errorf("requesting image url: %w", err)
-		}
-		defer func() {
-			if err := resp.Body.Close(); err != nil {
-				logrus.WithError(err).Error("closing image response (leaked fd)")
-			}
-		}()
-
-		imgFile, err := os.Create(filename) //#nosec:G304 // safely calculated path
-		if err != nil {
-			return "", fmt.Errorf("creating cache file: %w", err)
-		}
-
-		if _, err = io.Copy(imgFile, resp.Body); err != nil {
-			_ = imgFile.Close()
-			return "", fmt.Errorf("downloading file: %w", err)
-		}
-
-		if err = imgFile.Close(); err != nil {
-			return "", fmt.Errorf("closing cache file: %w", err)
-		}
+	if filename, err = helpers.CachedDownload(ctx, attributes.URL); err != nil {
+		return "", fmt.Errorf("downloading image url: %w", err)
 	}
 
 	return filename, nil
@@ -0,0 +1,140 @@
+// Package state provides display elements showing values of the shared
+// variable store.
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/displays/text"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules/opts"
+	log "github.com/sirupsen/logrus"
+)
+
+type (
+	// Display renders the look configured for the current value of a
+	// state key and renders it again whenever the value changes.
+	Display struct{}
+
+	// Attrs contains configuration for the state display. The look of
+	// the value is given by the inline text attributes with the fields
+	// set for the state of the value replacing them. Default is used as
+	// value while the key is not set.
+	Attrs struct {
+		Key     string                `json:"key,omitempty" yaml:"key,omitempty"`
+		Default string                `json:"default,omitempty" yaml:"default,omitempty"`
+		States  map[string]text.Attrs `json:"states,omitempty" yaml:"states,omitempty"`
+
+		text.Attrs `yaml:",inline"`
+	}
+)
+
+// Display renders the look for the current value of the key.
+func (Display) Display(ctx context.Context, idx int, devs opts.Runtime, atts config.DynamicAttributes) error {
+	attributes, err := opts.DecodeAttributes[Attrs](devs, atts)
+	if err != nil {
+		return fmt.Errorf("decoding attributes: %w", err)
+	}
+
+	if attributes.Key == "" {
+		return fmt.Errorf("no key supplied")
+	}
+
+	value := attributes.Default
+	if devs.State != nil {
+		if v, ok := devs.State.Get(attributes.Key); ok {
+			value = v
+		}
+	}
+
+	look := attributes.Attrs
+	if s, ok := attributes.States[value]; ok {
+		look = mergeLook(look, s)
+	}
+
+	return text.Display{}.Render(ctx, idx, devs, look) //nolint:wrapcheck // fine for this as that's a normal render module itself
+}
+
+// mergeLook returns the look with all fields set in the look of the
+// state replacing those of the look
+func mergeLook(look, state text.Attrs) text.Attrs {
+	if state.BackgroundColor != nil {
+		look.BackgroundColor = state.BackgroundColor
+	}
+
+	if state.Image != "" {
+		look.Image = state.Image
+	}
+
+	if state.RGBA != nil {
+		look.RGBA = state.RGBA
+	}
+
+	if state.FontSize != nil {
+		look.FontSize = state.FontSize
+	}
+
+	if state.Border != nil {
+		look.Border = state.Border
+	}
+
+	if state.Caption != "" {
+		look.Caption = state.Caption
+	}
+
+	if state.Text != "" {
+		look.Text = state.Text
+	}
+
+	return look
+}
+
+// NeedsLoop reports whether the display has a key to watch for changes.
+func (Display) NeedsLoop(atts config.DynamicAttributes) bool {
+	attributes, err := config.DecodeAttributes[Attrs](atts)
+	if err != nil {
+		return false
+	}
+
+	return attributes.Key != ""
+}
+
+// StartLoopDisplay renders the display and renders it again on every
+// change of the value of the key until the context is cancelled.
+// Changes of other values used in templates do not cause a redraw.
+func (d Display) StartLoopDisplay(ctx context.Context, idx int, devs opts.Runtime, atts config.DynamicAttributes) error {
+	attributes, err := opts.DecodeAttributes[Attrs](devs, atts)
+	if err != nil {
+		return fmt.Errorf("decoding attributes: %w", err)
+	}
+
+	if devs.State == nil {
+		return fmt.Errorf("no state store available")
+	}
+
+	// Watch before the first render to not miss a change in between
+	changes := devs.State.Watch(ctx, attributes.Key)
+
+	go func() {
+		for {
+			if err := d.Display(ctx, idx, devs, atts); err != nil {
+				if errors.Is(ctx.Err(), context.Canceled) {
+					return
+				}
+
+				log.WithError(err).Error("refreshing element")
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-changes:
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,250 @@
+package frames
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+	"time"
+)
+
+// Collection of APNG frame control values
+const (
+	apngDisposeBackground = 1
+	apngDisposePrevious   = 2
+
+	apngBlendSource = 0
+)
+
+const (
+	apngACTLSize = 8
+	apngFCTLSize = 26
+	apngSeqSize  = 4
+	pngIHDRSize  = 13
+)
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+type (
+	apngFrame struct {
+		rect     image.Rectangle
+		delay    time.Duration
+		dispose  byte
+		blend    byte
+		data     []byte
+		hasImage bool
+	}
+
+	pngChunk struct {
+		typ  string
+		data []byte
+	}
+)
+
+// decodeAPNG decodes an animated PNG. Frames are converted into
+// single PNG images decoded by image/png and composed according to
+// their dispose and blend operations. If the image is no APNG false is
+// returned without error.
+//
+//nolint:gocyclo // better to keep the chunk handling together
+func decodeAPNG(data []byte) (anim Animation, isAPNG bool, err error) {
+	chunks, err := readPNGChunks(data)
+	if err != nil {
+		return anim, false, err
+	}
+
+	var (
+		frames  []*apngFrame
+		ihdr    []byte
+		shared  []pngChunk
+		current *apngFrame
+	)
+
+	for _, c := range chunks {
+		switch c.typ {
+		case "IHDR":
+			if len(c.data) != pngIHDRSize {
+				return anim, false, fmt.Errorf("invalid IHDR chunk")
+			}
+			ihdr = c.data
+
+		case "PLTE", "tRNS":
+			// Required to decode every frame
+			shared = append(shared, c)
+
+		case "acTL":
+			if len(c.data) != apngACTLSize {
+				return anim, false, fmt.Errorf("invalid acTL chunk")
+			}
+			isAPNG = true
+			anim.Plays = int(binary.BigEndian.Uint32(c.data[4:]))
+
+		case "fcTL":
+			if len(c.data) != apngFCTLSize {
+				return anim, false, fmt.Errorf("invalid fcTL chunk")
+			}
+			current = parseFCTL(c.data)
+			frames = append(frames, current)
+
+		case "IDAT":
+			// Without preceding fcTL the default image is not part of
+			// the animation
+			if current != nil {
+				current.data = append(current.data, c.data...)
+				current.hasImage = true
+			}
+
+		case "fdAT":
+			if current == nil || len(c.data) < apngSeqSize {
+				return anim, false, fmt.Errorf("invalid fdAT chunk")
+			}
+			current.data = append(current.data, c.data[apngSeqSize:]...)
+			current.hasImage = true
+		}
+	}
+
+	if !isAPNG {
+		return anim, false, nil
+	}
+
+	if ihdr == nil {
+		return anim, true, fmt.Errorf("missing IHDR chunk")
+	}
+
+	var (
+		width  = int(binary.BigEndian.Uint32(ihdr[0:]))
+		height = int(binary.BigEndian.Uint32(ihdr[4:]))
+		canvas = image.NewRGBA(image.Rect(0, 0, width, height))
+	)
+
+	for i, frame := range frames {
+		if !frame.hasImage {
+			continue
+		}
+
+		img, err := decodeAPNGFrame(ihdr, shared, frame)
+		if err != nil {
+			return anim, true, fmt.Errorf("decoding frame %d: %w", i, err)
+		}
+
+		dispose := frame.dispose
+		if i == 0 && dispose == apngDisposePrevious {
+			// There is no previous frame to restore
+			dispose = apngDisposeBackground
+		}
+
+		var previous *image.RGBA
+		if dispose == apngDisposePrevious {
+			previous = cloneRGBA(canvas)
+		}
+
+		op := draw.Over
+		if frame.blend == apngBlendSource {
+			op = draw.Src
+		}
+		draw.Draw(canvas, frame.rect, img, img.Bounds().Min, op)
+
+		anim.Frames = append(anim.Frames, cloneRGBA(canvas))
+		anim.Delays = append(anim.Delays, frame.delay)
+
+		switch dispose {
+		case apngDisposeBackground:
+			draw.Draw(canvas, frame.rect, image.Transparent, image.Point{}, draw.Src)
+		case apngDisposePrevious:
+			canvas = previous
+		}
+	}
+
+	if len(anim.Frames) == 0 {
+		return anim, true, fmt.Errorf("no frames found")
+	}
+
+	return anim, true, nil
+}
+
+// decodeAPNGFrame builds a single PNG image from the frame data and
+// decodes it
+func decodeAPNGFrame(ihdr []byte, shared []pngChunk, frame *apngFrame) (image.Image, error) {
+	frameIHDR := bytes.Clone(ihdr)
+	binary.BigEndian.PutUint32(frameIHDR[0:], uint32(frame.rect.Dx())) //#nosec:G115 // read from uint32
+	binary.BigEndian.PutUint32(frameIHDR[4:], uint32(frame.rect.Dy())) //#nosec:G115 // read from uint32
+
+	buf := bytes.NewBuffer(bytes.Clone(pngSignature))
+	writePNGChunk(buf, pngChunk{"IHDR", frameIHDR})
+	for _, c := range shared {
+		writePNGChunk(buf, c)
+	}
+	writePNGChunk(buf, pngChunk{"IDAT", frame.data})
+	writePNGChunk(buf, pngChunk{"IEND", nil})
+
+	img, err := png.Decode(buf)
+	if err != nil {
+		return nil, fmt.Errorf("decoding PNG: %w", err)
+	}
+
+	return img, nil
+}
+
+func parseFCTL(data []byte) *apngFrame {
+	var (
+		width    = int(binary.BigEndian.Uint32(data[4:]))
+		height   = int(binary.BigEndian.Uint32(data[8:]))
+		x        = int(binary.BigEndian.Uint32(data[12:]))
+		y        = int(binary.BigEndian.Uint32(data[16:]))
+		delayNum = binary.BigEndian.Uint16(data[20:])
+		delayDen = binary.BigEndian.Uint16(data[22:])
+	)
+
+	if delayDen == 0 {
+		// Specification defines a zero denominator as 1/100s
+		delayDen = 100
+	}
+
+	return &apngFrame{
+		rect:    image.Rect(x, y, x+width, y+height),
+		delay:   time.Duration(delayNum) * time.Second / time.Duration(delayDen),
+		dispose: data[24],
+		blend:   data[25],
+	}
+}
+
+func readPNGChunks(data []byte) (chunks []pngChunk, err error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, fmt.Errorf("missing PNG signature")
+	}
+
+	const chunkOverhead = 12 // length, type and CRC
+
+	for pos := len(pngSignature); pos < len(data); {
+		if len(data)-pos < chunkOverhead {
+			return nil, fmt.Errorf("truncated chunk at offset %d", pos)
+		}
+
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		if length < 0 || len(data)-pos-chunkOverhead < length {
+			return nil, fmt.Errorf("truncated chunk at offset %d", pos)
+		}
+
+		chunks = append(chunks, pngChunk{
+			typ:  string(data[pos+4 : pos+8]),
+			data: data[pos+8 : pos+8+length],
+		})
+		pos += chunkOverhead + length
+	}
+
+	return chunks, nil
+}
+
+func writePNGChunk(buf *bytes.Buffer, c pngChunk) {
+	crc := crc32.NewIEEE()
+	_, _ = crc.Write([]byte(c.typ))
+	_, _ = crc.Write(c.data)
+
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(c.data))) //#nosec:G115 // chunk data read from uint32 length
+	buf.WriteString(c.typ)
+	buf.Write(c.data)
+	_ = binary.Write(buf, binary.BigEndian, crc.Sum32())
+}
@@ -0,0 +1,87 @@
+package frames
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultClockInterval limits animations to 25 frames per second
+const defaultClockInterval = 40 * time.Millisecond
+
+// DefaultClock is the clock shared by all animations
+var DefaultClock = NewClock(defaultClockInterval)
+
+// Clock emits ticks to all subscribers at the same time so animations
+// advance their frames together and their writes to the device are
+// grouped instead of spread across the whole interval. The clock only
+// runs while there are subscribers.
+type Clock struct {
+	interval time.Duration
+
+	lock   sync.Mutex
+	subs   map[chan time.Time]struct{}
+	ticker *time.Ticker
+	stop   chan struct{}
+}
+
+// NewClock creates a Clock ticking at the given interval
+func NewClock(interval time.Duration) *Clock {
+	return &Clock{
+		interval: interval,
+		subs:     make(map[chan time.Time]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving the ticks of the clock until
+// the context is cancelled. Ticks are dropped when the subscriber is
+// still busy with the previous one.
+func (c *Clock) Subscribe(ctx context.Context) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+
+	c.lock.Lock()
+	c.subs[ch] = struct{}{}
+	if c.ticker == nil {
+		c.ticker = time.NewTicker(c.interval)
+		c.stop = make(chan struct{})
+		go c.run(c.ticker, c.stop)
+	}
+	c.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		delete(c.subs, ch)
+		close(ch)
+
+		if len(c.subs) == 0 {
+			c.ticker.Stop()
+			close(c.stop)
+			c.ticker = nil
+		}
+	}()
+
+	return ch
+}
+
+func (c *Clock) run(ticker *time.Ticker, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+
+		case now := <-ticker.C:
+			c.lock.Lock()
+			for ch := range c.subs {
+				select {
+				case ch <- now:
+				default:
+				}
+			}
+			c.lock.Unlock()
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// Package frames decodes animated images and plays them in sync with
+// other animations.
+package frames
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"os"
+	"time"
+
+	_ "image/jpeg" // Register JPEG decoder for still images
+	_ "image/png"  // Register PNG decoder for still images
+)
+
+const (
+	// defaultFrameDelay is used for frames without delay
+	defaultFrameDelay = 100 * time.Millisecond
+	// gifDelayUnit is the unit of delays stored in GIF files
+	gifDelayUnit = 10 * time.Millisecond
+)
+
+// Animation contains the frames of an image with the duration each
+// frame is shown. Still images are animations with a single frame.
+type Animation struct {
+	Frames []image.Image
+	Delays []time.Duration
+	// Plays is the number of times the animation is played, zero to
+	// play it forever
+	Plays int
+}
+
+// Decode reads a GIF, APNG, PNG or JPEG image. Frames of animations
+// are composed into full images.
+func Decode(data []byte) (Animation, error) {
+	if bytes.HasPrefix(data, []byte("GIF8")) {
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return Animation{}, fmt.Errorf("decoding GIF: %w", err)
+		}
+
+		return decodeGIF(anim), nil
+	}
+
+	if bytes.HasPrefix(data, pngSignature) {
+		anim, isAPNG, err := decodeAPNG(data)
+		if err != nil {
+			return Animation{}, fmt.Errorf("decoding APNG: %w", err)
+		}
+
+		if isAPNG {
+			return anim, nil
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Animation{}, fmt.Errorf("decoding image: %w", err)
+	}
+
+	return Animation{Frames: []image.Image{img}, Delays: []time.Duration{0}}, nil
+}
+
+// Load reads and decodes the given image file (see Decode)
+func Load(filename string) (Animation, error) {
+	data, err := os.ReadFile(filename) //#nosec:G304 // intended to read configured image
+	if err != nil {
+		return Animation{}, fmt.Errorf("reading file: %w", err)
+	}
+
+	return Decode(data)
+}
+
+// SpriteSheet cuts frames of the given size from the image, reading
+// left to right and top to bottom. A count of zero uses all frames
+// fitting into the image.
+func SpriteSheet(img image.Image, frameSize image.Point, count int, delay time.Duration) (Animation, error) {
+	if frameSize.X <= 0 || frameSize.Y <= 0 {
+		return Animation{}, fmt.Errorf("invalid frame size %s", frameSize)
+	}
+
+	var (
+		bounds = img.Bounds()
+		cols   = bounds.Dx() / frameSize.X
+		rows   = bounds.Dy() / frameSize.Y
+	)
+
+	if count <= 0 || count > cols*rows {
+		count = cols * rows
+	}
+
+	if count == 0 {
+		return Animation{}, fmt.Errorf("sprite sheet smaller than frame size %s", frameSize)
+	}
+
+	if delay <= 0 {
+		delay = defaultFrameDelay
+	}
+
+	anim := Animation{}
+	for i := range count {
+		rect := image.Rectangle{Max: frameSize}.Add(bounds.Min).Add(image.Pt(i%cols*frameSize.X, i/cols*frameSize.Y))
+
+		frame := image.NewRGBA(image.Rectangle{Max: frameSize})
+		draw.Draw(frame, frame.Bounds(), img, rect.Min, draw.Src)
+
+		anim.Frames = append(anim.Frames, frame)
+		anim.Delays = append(anim.Delays, delay)
+	}
+
+	return anim, nil
+}
+
+// Play shows the first frame and advances the frames on the ticks of
+// the clock until the animation finished all its plays or the context
+// is cancelled. The last shown frame stays on display. Errors of show
+// stop the playback and are returned.
+func (a Animation) Play(ctx context.Context, clock *Clock, show func(frame int) error) error {
+	return a.play(time.Now(), func() <-chan time.Time { return clock.Subscribe(ctx) }, show)
+}
+
+// play shows the frames starting at the given time and advances them
+// on the ticks until the animation finished or the ticks are closed
+func (a Animation) play(start time.Time, subscribe func() <-chan time.Time, show func(frame int) error) error {
+	if len(a.Frames) == 0 {
+		return fmt.Errorf("animation has no frames")
+	}
+
+	if err := show(0); err != nil {
+		return err
+	}
+
+	if len(a.Frames) == 1 {
+		return nil
+	}
+
+	var (
+		frame   int
+		plays   int
+		shownAt = start
+		ticks   = subscribe()
+	)
+
+	for now := range ticks {
+		var (
+			next, at = frame, shownAt
+			finished bool
+		)
+
+		for !finished && now.Sub(at) >= a.delay(next) {
+			at = at.Add(a.delay(next))
+
+			if next++; next == len(a.Frames) {
+				if plays++; a.Plays > 0 && plays >= a.Plays {
+					// Stay on the last frame
+					next, finished = len(a.Frames)-1, true
+					continue
+				}
+				next = 0
+			}
+		}
+
+		if next != frame {
+			frame, shownAt = next, at
+			if err := show(frame); err != nil {
+				return err
+			}
+		}
+
+		if finished {
+			return nil
+		}
+	}
+
+	// Ticks were stopped (context was cancelled)
+	return nil
+}
+
+// delay returns the delay of the frame, frames without delay are
+// shown for one tick of the clock
+func (a Animation) delay(frame int) time.Duration {
+	if frame < len(a.Delays) && a.Delays[frame] > 0 {
+		return a.Delays[frame]
+	}
+
+	return time.Nanosecond
+}
+
+func decodeGIF(src *gif.GIF) Animation {
+	var (
+		anim   = Animation{Plays: gifPlays(src.LoopCount)}
+		canvas = image.NewRGBA(image.Rect(0, 0, src.Config.Width, src.Config.Height))
+	)
+
+	for i, frame := range src.Image {
+		var disposal byte
+		if i < len(src.Disposal) {
+			disposal = src.Disposal[i]
+		}
+
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = cloneRGBA(canvas)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		anim.Frames = append(anim.Frames, cloneRGBA(canvas))
+
+		delay := defaultFrameDelay
+		if i < len(src.Delay) && src.Delay[i] > 0 {
+			delay = time.Duration(src.Delay[i]) * gifDelayUnit
+		}
+		anim.Delays = append(anim.Delays, delay)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	return anim
+}
+
+// gifPlays converts the GIF loop count (number of restarts, -1 for no
+// restart) into the number of plays
+func gifPlays(loopCount int) int {
+	switch {
+	case loopCount < 0:
+		return 1
+	case loopCount == 0:
+		return 0
+	default:
+		return loopCount + 1
+	}
+}
+
+func cloneRGBA(img *image.RGBA) *image.RGBA {
+	out := image.NewRGBA(img.Bounds())
+	draw.Draw(out, out.Bounds(), img, img.Bounds().Min, draw.Src)
+	return out
+}
@@ -0,0 +1,248 @@
+package frames
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/png"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testRed  = color.RGBA{0xff, 0x0, 0x0, 0xff}
+	testBlue = color.RGBA{0x0, 0x0, 0xff, 0xff}
+)
+
+func solidImage(size int, col color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = col.R, col.G, col.B, col.A
+	}
+
+	return img
+}
+
+// buildAPNG creates an animated PNG from the given frames all having
+// the same size and a delay of 1/10s
+func buildAPNG(t *testing.T, plays uint32, imgs ...image.Image) []byte {
+	t.Helper()
+
+	buf := bytes.NewBuffer(bytes.Clone(pngSignature))
+	seq := uint32(0)
+
+	for i, img := range imgs {
+		enc := new(bytes.Buffer)
+		require.NoError(t, png.Encode(enc, img))
+
+		chunks, err := readPNGChunks(enc.Bytes())
+		require.NoError(t, err)
+
+		fctl := make([]byte, apngFCTLSize)
+		binary.BigEndian.PutUint32(fctl[0:], seq)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(img.Bounds().Dx())) //#nosec:G115 // test data
+		binary.BigEndian.PutUint32(fctl[8:], uint32(img.Bounds().Dy())) //#nosec:G115 // test data
+		binary.BigEndian.PutUint16(fctl[20:], 1)
+		binary.BigEndian.PutUint16(fctl[22:], 10)
+		seq++
+
+		for _, c := range chunks {
+			switch {
+			case c.typ == "IHDR" && i == 0:
+				writePNGChunk(buf, c)
+
+				actl := make([]byte, apngACTLSize)
+				binary.BigEndian.PutUint32(actl[0:], uint32(len(imgs))) //#nosec:G115 // test data
+				binary.BigEndian.PutUint32(actl[4:], plays)
+				writePNGChunk(buf, pngChunk{"acTL", actl})
+
+			case c.typ == "IDAT" && i == 0:
+				if fctl != nil {
+					writePNGChunk(buf, pngChunk{"fcTL", fctl})
+					fctl = nil
+				}
+				writePNGChunk(buf, c)
+
+			case c.typ == "IDAT":
+				if fctl != nil {
+					writePNGChunk(buf, pngChunk{"fcTL", fctl})
+					fctl = nil
+				}
+
+				fdat := binary.BigEndian.AppendUint32(nil, seq)
+				seq++
+				writePNGChunk(buf, pngChunk{"fdAT", append(fdat, c.data...)})
+			}
+		}
+	}
+
+	writePNGChunk(buf, pngChunk{"IEND", nil})
+	return buf.Bytes()
+}
+
+func TestDecodeGIF(t *testing.T) {
+	t.Parallel()
+
+	src := &gif.GIF{LoopCount: 2, Delay: []int{5, 0}}
+	for _, col := range []color.RGBA{testRed, testBlue} {
+		frame := image.NewPaletted(image.Rect(0, 0, 4, 4), palette.Plan9)
+		for i := range frame.Pix {
+			frame.Pix[i] = uint8(frame.Palette.Index(col)) //#nosec:G115 // palette has 256 colors
+		}
+		src.Image = append(src.Image, frame)
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, gif.EncodeAll(buf, src))
+
+	anim, err := Decode(buf.Bytes())
+	require.NoError(t, err)
+
+	require.Len(t, anim.Frames, 2)
+	assert.Equal(t, []time.Duration{50 * time.Millisecond, defaultFrameDelay}, anim.Delays)
+	assert.Equal(t, 3, anim.Plays)
+	assert.Equal(t, testRed, anim.Frames[0].At(1, 1))
+	assert.Equal(t, testBlue, anim.Frames[1].At(1, 1))
+}
+
+func TestDecodeAPNG(t *testing.T) {
+	t.Parallel()
+
+	anim, err := Decode(buildAPNG(t, 4, solidImage(4, testRed), solidImage(4, testBlue)))
+	require.NoError(t, err)
+
+	require.Len(t, anim.Frames, 2)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 100 * time.Millisecond}, anim.Delays)
+	assert.Equal(t, 4, anim.Plays)
+	assert.Equal(t, testRed, anim.Frames[0].At(1, 1))
+	assert.Equal(t, testBlue, anim.Frames[1].At(1, 1))
+
+	// Plain PNG is a still image
+	buf := new(bytes.Buffer)
+	require.NoError(t, png.Encode(buf, solidImage(4, testRed)))
+
+	anim, err = Decode(buf.Bytes())
+	require.NoError(t, err)
+	assert.Len(t, anim.Frames, 1)
+}
+
+func TestSpriteSheet(t *testing.T) {
+	t.Parallel()
+
+	sheet := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for i, col := range []color.RGBA{testRed, testBlue, testRed, testBlue} {
+		x, y := i%2*4, i/2*4
+		for px := x; px < x+4; px++ {
+			for py := y; py < y+4; py++ {
+				sheet.Set(px, py, col)
+			}
+		}
+	}
+
+	anim, err := SpriteSheet(sheet, image.Pt(4, 4), 3, 0)
+	require.NoError(t, err)
+
+	require.Len(t, anim.Frames, 3)
+	assert.Equal(t, []time.Duration{defaultFrameDelay, defaultFrameDelay, defaultFrameDelay}, anim.Delays)
+	assert.Equal(t, testBlue, anim.Frames[1].At(0, 0))
+	assert.Equal(t, testRed, anim.Frames[2].At(3, 3))
+
+	_, err = SpriteSheet(sheet, image.Pt(16, 16), 0, 0)
+	require.Error(t, err)
+}
+
+// fakeTicks returns a subscription to ticks at the given offsets from
+// the start, the ticks are closed after the last one
+func fakeTicks(start time.Time, offsets ...time.Duration) func() <-chan time.Time {
+	return func() <-chan time.Time {
+		ticks := make(chan time.Time, len(offsets))
+		for _, o := range offsets {
+			ticks <- start.Add(o)
+		}
+		close(ticks)
+
+		return ticks
+	}
+}
+
+func TestPlay(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ms    = time.Millisecond
+		start = time.Now()
+		anim  = Animation{
+			Frames: []image.Image{solidImage(1, testRed), solidImage(1, testBlue), solidImage(1, testRed)},
+			Delays: []time.Duration{5 * ms, 5 * ms, 5 * ms},
+			Plays:  2,
+		}
+		shown []int
+		show  = func(frame int) error {
+			shown = append(shown, frame)
+			return nil
+		}
+	)
+
+	// All frames are shown in both plays, finishing on the last one,
+	// ticks between frame changes do not show a frame
+	require.NoError(t, anim.play(start, fakeTicks(start, 1*ms, 5*ms, 7*ms, 10*ms, 15*ms, 20*ms, 25*ms, 30*ms), show))
+	assert.Equal(t, []int{0, 1, 2, 0, 1, 2}, shown)
+
+	// Ticks slower than the frames still finish on the last frame
+	shown = nil
+	require.NoError(t, Animation{Frames: anim.Frames, Delays: anim.Delays, Plays: 1}.play(start, fakeTicks(start, 20*ms), show))
+	assert.Equal(t, []int{0, 2}, shown)
+
+	// Frames are skipped when ticks are late
+	shown = nil
+	require.NoError(t, anim.play(start, fakeTicks(start, 11*ms, 21*ms), show))
+	assert.Equal(t, []int{0, 2, 1}, shown)
+
+	// Endless animations stop when the ticks stop
+	shown = nil
+	require.NoError(t, Animation{Frames: anim.Frames, Delays: anim.Delays}.play(start, fakeTicks(start, 5*ms, 10*ms, 15*ms, 20*ms), show))
+	assert.Equal(t, []int{0, 1, 2, 0, 1}, shown)
+
+	clock := NewClock(time.Millisecond)
+
+	// Endless animations stop on context cancel
+	ctx, cancel := context.WithCancel(t.Context())
+
+	var (
+		lock  sync.Mutex
+		count int
+	)
+
+	anim.Plays = 0
+	done := make(chan error)
+	go func() {
+		done <- anim.Play(ctx, clock, func(int) error {
+			lock.Lock()
+			defer lock.Unlock()
+			count++
+			return nil
+		})
+	}()
+
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return count > len(anim.Frames)
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("animation not stopped after cancel")
+	}
+}
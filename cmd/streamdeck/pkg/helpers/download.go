@@ -0,0 +1,74 @@
+package helpers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/sirupsen/logrus"
+)
+
+const cacheDirMode = 0o700
+
+// CachedDownload downloads the given URL into the user cache dir and
+// returns the name of the cached file. Already cached URLs are not
+// downloaded again.
+func CachedDownload(ctx context.Context, url string) (filename string, err error) {
+	filename, err = cacheFileName(url)
+	if err != nil {
+		return "", fmt.Errorf("getting cache filename for url: %w", err)
+	}
+
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		return filename, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting url: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.WithError(err).Error("closing download response (leaked fd)")
+		}
+	}()
+
+	cacheFile, err := os.Create(filename) //#nosec:G304 // safely calculated path
+	if err != nil {
+		return "", fmt.Errorf("creating cache file: %w", err)
+	}
+
+	if _, err = io.Copy(cacheFile, resp.Body); err != nil {
+		_ = cacheFile.Close()
+		return "", fmt.Errorf("downloading file: %w", err)
+	}
+
+	if err = cacheFile.Close(); err != nil {
+		return "", fmt.Errorf("closing cache file: %w", err)
+	}
+
+	return filename, nil
+}
+
+func cacheFileName(url string) (string, error) {
+	ucd, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("getting user cache dir: %w", err)
+	}
+
+	cacheDir := path.Join(ucd, "io.luzifer.streamdeck")
+	if err = os.MkdirAll(cacheDir, cacheDirMode); err != nil {
+		return "", fmt.Errorf("creating cache dir: %w", err)
+	}
+
+	return path.Join(cacheDir, fmt.Sprintf("%x", sha256.Sum256([]byte(url)))), nil
+}
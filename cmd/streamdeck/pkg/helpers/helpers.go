@@ -13,24 +13,30 @@ import (
 
 // AutoSizeImage scales and pads an image to the requested square size.
 func AutoSizeImage(img image.Image, size int) image.Image {
-	if img.Bounds().Max.X == size && img.Bounds().Max.Y == size {
+	return FitImage(img, image.Pt(size, size))
+}
+
+// FitImage scales and pads an image to the requested size keeping its
+// aspect ratio.
+func FitImage(img image.Image, size image.Point) image.Image {
+	if img.Bounds().Max == size {
 		// Image has perfect size: Nothing to change
 		return img
 	}
 
 	// Scale down when required
-	img = resize.Thumbnail(uint(size), uint(size), img, resize.Lanczos2)
-	if img.Bounds().Max.X == size && img.Bounds().Max.Y == size {
+	img = resize.Thumbnail(uint(size.X), uint(size.Y), img, resize.Lanczos2)
+	if img.Bounds().Max == size {
 		// Image has perfect size now: Nothing to change
 		return img
 	}
 
 	// Image is too small, need to pad it
 	var (
-		dimg = image.NewNRGBA(image.Rect(0, 0, size, size))
+		dimg = image.NewNRGBA(image.Rectangle{Max: size})
 		pt   = image.Point{
-			X: (size - img.Bounds().Max.X) / 2,
-			Y: (size - img.Bounds().Max.Y) / 2,
+			X: (size.X - img.Bounds().Max.X) / 2,
+			Y: (size.Y - img.Bounds().Max.Y) / 2,
 		}
 	)
 	// Draw black background
@@ -10,6 +10,7 @@ import (
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/displays/color"
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules/opts"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/templating"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -39,23 +40,31 @@ type (
 
 var (
 	registeredActions             = make(map[string]reflect.Type)
+	registeredActionAttributes    = make(map[string]reflect.Type)
 	registeredActionsLock         sync.Mutex
 	registeredDisplayElements     = make(map[string]reflect.Type)
+	registeredDisplayAttributes   = make(map[string]reflect.Type)
 	registeredDisplayElementsLock sync.Mutex
 )
 
-func registerAction(name string, handler Action) {
+// registerAction registers the action by its type name with the
+// attributes it decodes (nil for actions without attributes)
+func registerAction(name string, handler Action, attrs any) {
 	registeredActionsLock.Lock()
 	defer registeredActionsLock.Unlock()
 
 	registeredActions[name] = reflect.TypeOf(handler)
+	registeredActionAttributes[name] = reflect.TypeOf(attrs)
 }
 
-func registerDisplayElement(name string, handler DisplayElement) {
+// registerDisplayElement registers the display by its type name with
+// the attributes it decodes
+func registerDisplayElement(name string, handler DisplayElement, attrs any) {
 	registeredDisplayElementsLock.Lock()
 	defer registeredDisplayElementsLock.Unlock()
 
 	registeredDisplayElements[name] = reflect.TypeOf(handler)
+	registeredDisplayAttributes[name] = reflect.TypeOf(attrs)
 }
 
 // CallAction instantiates and executes a registered action.
@@ -75,16 +84,9 @@ func CallAction(dev opts.Runtime, a config.DynamicElement) (err error) {
 
 // CallDisplayElement instantiates and renders a registered display element.
 func CallDisplayElement(ctx context.Context, idx int, dev opts.Runtime, kd config.KeyDefinition) (err error) {
-	t, ok := registeredDisplayElements[kd.Display.Type]
-	if !ok {
-		return fmt.Errorf("unknown display type %q", kd.Display.Type)
-	}
-
-	var inst any
-	if t.Kind() == reflect.Pointer {
-		inst = reflect.New(t.Elem()).Interface()
-	} else {
-		inst = reflect.New(t).Interface()
+	inst, err := newDisplayElement(kd.Display.Type)
+	if err != nil {
+		return err
 	}
 
 	if loop, ok := inst.(RefreshingDisplayElement); ok && loop.NeedsLoop(kd.Display.Attributes) {
@@ -108,18 +110,43 @@ func CallDisplayElement(ctx context.Context, idx int, dev opts.Runtime, kd confi
 	return nil
 }
 
-// CallErrorDisplayElement renders the fallback error display on a key.
-func CallErrorDisplayElement(ctx context.Context, idx int, dev opts.Runtime) (err error) {
-	t, ok := registeredDisplayElements[errorDisplayElementType]
-	if !ok {
-		return fmt.Errorf("unknown display type %q", errorDisplayElementType)
+// CallDisplayElementOnce instantiates a registered display element and
+// renders it a single time without starting periodic refreshes or
+// animations (which show their first frame) to take a snapshot of it.
+func CallDisplayElementOnce(ctx context.Context, idx int, dev opts.Runtime, kd config.KeyDefinition) (err error) {
+	inst, err := newDisplayElement(kd.Display.Type)
+	if err != nil {
+		return err
 	}
 
-	var inst any
-	if t.Kind() == reflect.Pointer {
-		inst = reflect.New(t.Elem()).Interface()
-	} else {
-		inst = reflect.New(t).Interface()
+	if err = inst.(DisplayElement).
+		Display(ctx, idx, dev, kd.Display.Attributes); err != nil {
+		return fmt.Errorf("displaying element: %w", err)
+	}
+
+	return nil
+}
+
+// IsStaticDisplay reports whether the display of the key is a known
+// display type always rendering the same content for its attributes.
+// Displays executing commands, fetching remote content, refreshing
+// periodically or having templates within their attributes are not
+// static.
+func IsStaticDisplay(kd config.KeyDefinition) bool {
+	t, ok := registeredDisplayElements[kd.Display.Type]
+	if !ok || templating.HasTemplate(kd.Display.Attributes) {
+		return false
+	}
+
+	return !t.Implements(reflect.TypeFor[RefreshingDisplayElement]()) &&
+		!reflect.PointerTo(t).Implements(reflect.TypeFor[RefreshingDisplayElement]())
+}
+
+// CallErrorDisplayElement renders the fallback error display on a key.
+func CallErrorDisplayElement(ctx context.Context, idx int, dev opts.Runtime) (err error) {
+	inst, err := newDisplayElement(errorDisplayElementType)
+	if err != nil {
+		return err
 	}
 
 	attrs, err := config.EncodeAttributes(color.Attrs{
@@ -135,3 +162,16 @@ func CallErrorDisplayElement(ctx context.Context, idx int, dev opts.Runtime) (er
 
 	return nil
 }
+
+func newDisplayElement(displayType string) (any, error) {
+	t, ok := registeredDisplayElements[displayType]
+	if !ok {
+		return nil, fmt.Errorf("unknown display type %q", displayType)
+	}
+
+	if t.Kind() == reflect.Pointer {
+		return reflect.New(t.Elem()).Interface(), nil
+	}
+
+	return reflect.New(t).Interface(), nil
+}
@@ -0,0 +1,323 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules/opts"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font/gofont/goregular"
+
+	"github.com/Luzifer/streamdeck/v2"
+)
+
+func newFakeRuntime(t *testing.T) (opts.Runtime, *streamdeck.FakeDeck) {
+	t.Helper()
+
+	fake, err := streamdeck.NewFakeDeck(streamdeck.StreamDeckMini)
+	require.NoError(t, err)
+
+	deck, err := streamdeck.NewWithTransport(streamdeck.StreamDeckMini, fake)
+	require.NoError(t, err)
+
+	return opts.Runtime{Conf: config.New(), Deck: deck}, fake
+}
+
+func TestCallDisplayElementRendersOnDeck(t *testing.T) {
+	t.Parallel()
+
+	rt, fake := newFakeRuntime(t)
+
+	attrs, err := config.EncodeAttributes(map[string]any{"rgba": []int{0x0, 0xff, 0x0, 0xff}})
+	require.NoError(t, err)
+
+	require.NoError(t, CallDisplayElement(context.Background(), 3, rt, config.KeyDefinition{
+		Display: config.DynamicElement{Type: "color", Attributes: attrs},
+	}))
+
+	img := fake.KeyImage(3)
+	require.NotNil(t, img)
+	assert.Equal(t, color.RGBA{0x0, 0xff, 0x0, 0xff}, color.RGBAModel.Convert(img.At(img.Bounds().Min.X, img.Bounds().Min.Y)))
+}
+
+func TestCallErrorDisplayElement(t *testing.T) {
+	t.Parallel()
+
+	rt, fake := newFakeRuntime(t)
+
+	require.NoError(t, CallErrorDisplayElement(context.Background(), 0, rt))
+
+	img := fake.KeyImage(0)
+	require.NotNil(t, img)
+	assert.Equal(t, color.RGBA{0xff, 0x0, 0x0, 0xff}, color.RGBAModel.Convert(img.At(img.Bounds().Min.X, img.Bounds().Min.Y)))
+}
+
+func TestCallActionUnknownType(t *testing.T) {
+	t.Parallel()
+
+	rt, _ := newFakeRuntime(t)
+
+	assert.Error(t, CallAction(rt, config.DynamicElement{Type: "does_not_exist"}))
+}
+
+func TestCallDisplayElementPlaysAnimation(t *testing.T) {
+	t.Parallel()
+
+	rt, fake := newFakeRuntime(t)
+
+	// Two frame GIF played once: red for 20ms, then blue
+	anim := &gif.GIF{LoopCount: -1, Delay: []int{2, 2}}
+	for _, col := range []color.RGBA{{0xff, 0x0, 0x0, 0xff}, {0x0, 0x0, 0xff, 0xff}} {
+		frame := image.NewPaletted(image.Rect(0, 0, 16, 16), color.Palette{col})
+		anim.Image = append(anim.Image, frame)
+	}
+
+	filename := path.Join(t.TempDir(), "anim.gif")
+	f, err := os.Create(filename) //#nosec:G304 // test file
+	require.NoError(t, err)
+	require.NoError(t, gif.EncodeAll(f, anim))
+	require.NoError(t, f.Close())
+
+	attrs, err := config.EncodeAttributes(map[string]any{"path": filename})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, CallDisplayElement(ctx, 1, rt, config.KeyDefinition{
+		Display: config.DynamicElement{Type: "animation", Attributes: attrs},
+	}))
+
+	assert.Eventually(t, func() bool {
+		img := fake.KeyImage(1)
+		if img == nil {
+			return false
+		}
+
+		center := img.Bounds().Min.Add(img.Bounds().Size().Div(2))
+		r, _, b, _ := img.At(center.X, center.Y).RGBA()
+		return b>>8 > 0xe0 && r>>8 < 0x20
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestIsStaticDisplay(t *testing.T) {
+	t.Parallel()
+
+	for typ, static := range map[string]bool{
+		"animation": false,
+		"color":     true,
+		"exec":      false,
+		"http":      false,
+		"image":     true,
+		"state":     false,
+		"text":      true,
+		"unknown":   false,
+	} {
+		kd := config.KeyDefinition{Display: config.DynamicElement{Type: typ}}
+		assert.Equal(t, static, IsStaticDisplay(kd), typ)
+	}
+
+	for typ, attrs := range map[string]any{
+		"color": map[string]string{"color": "{{ .State.led }}"},
+		"image": map[string]string{"path": "/icons/{{ .Page }}.png"},
+		"text":  map[string]string{"caption": "fixed", "text": "{{ now | date \"15:04\" }}"},
+	} {
+		encoded, err := config.EncodeAttributes(attrs)
+		require.NoError(t, err)
+
+		kd := config.KeyDefinition{Display: config.DynamicElement{Type: typ, Attributes: encoded}}
+		assert.False(t, IsStaticDisplay(kd), typ)
+	}
+}
+
+func TestCallActionSetsStateForTemplates(t *testing.T) {
+	t.Parallel()
+
+	rt, fake := newFakeRuntime(t)
+	rt.Key, rt.Page, rt.State = 2, "main", state.New()
+
+	attrs, err := config.EncodeAttributes(map[string]string{"key": "led", "value": "{{ if eq .Page \"main\" }}blue{{ end }}"})
+	require.NoError(t, err)
+	require.NoError(t, CallAction(rt, config.DynamicElement{Type: "state", Attributes: attrs}))
+
+	v, ok := rt.State.Get("led")
+	require.True(t, ok)
+	assert.Equal(t, "blue", v)
+
+	attrs, err = config.EncodeAttributes(map[string]string{"color": "{{ .State.led }}"})
+	require.NoError(t, err)
+	require.NoError(t, CallDisplayElement(context.Background(), rt.Key, rt, config.KeyDefinition{
+		Display: config.DynamicElement{Type: "color", Attributes: attrs},
+	}))
+
+	img := fake.KeyImage(2)
+	require.NotNil(t, img)
+	assert.Equal(t, color.RGBA{0x0, 0x0, 0xff, 0xff}, color.RGBAModel.Convert(img.At(img.Bounds().Min.X, img.Bounds().Min.Y)))
+}
+
+func TestStateDisplayRedrawsOnChange(t *testing.T) {
+	t.Parallel()
+
+	rt, fake := newFakeRuntime(t)
+	rt.State = state.New()
+
+	attrs, err := config.EncodeAttributes(map[string]any{
+		"key":     "mic",
+		"default": "on",
+		"states": map[string]any{
+			"on":  map[string]any{"background_color": []int{0x0, 0xff, 0x0, 0xff}},
+			"off": map[string]any{"background_color": []int{0xff, 0x0, 0x0, 0xff}},
+		},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, CallDisplayElement(ctx, 0, rt, config.KeyDefinition{
+		Display: config.DynamicElement{Type: "state", Attributes: attrs},
+	}))
+
+	keyColor := func() color.RGBA {
+		img := fake.KeyImage(0)
+		if img == nil {
+			return color.RGBA{}
+		}
+		return color.RGBAModel.Convert(img.At(img.Bounds().Min.X, img.Bounds().Min.Y)).(color.RGBA) //nolint:forcetypeassert // converted by model
+	}
+
+	assert.Eventually(t, func() bool { return keyColor() == color.RGBA{0x0, 0xff, 0x0, 0xff} }, time.Second, 5*time.Millisecond)
+
+	attrs, err = config.EncodeAttributes(map[string]any{"key": "mic", "operation": "toggle", "values": []string{"on", "off"}})
+	require.NoError(t, err)
+	require.NoError(t, CallAction(rt, config.DynamicElement{Type: "state", Attributes: attrs}))
+
+	v, _ := rt.State.Get("mic")
+	require.Equal(t, "on", v)
+
+	require.NoError(t, CallAction(rt, config.DynamicElement{Type: "state", Attributes: attrs}))
+	assert.Eventually(t, func() bool { return keyColor() == color.RGBA{0xff, 0x0, 0x0, 0xff} }, time.Second, 5*time.Millisecond)
+}
+
+func TestToggleDisplayKeepsBrightnessInState(t *testing.T) {
+	t.Parallel()
+
+	rt, _ := newFakeRuntime(t)
+	rt.Serial, rt.State = "AL01", state.New()
+	rt.Conf.DefaultBrightness = 80
+
+	for _, expect := range []string{"0", "80", "0"} {
+		require.NoError(t, CallAction(rt, config.DynamicElement{Type: "toggle_display"}))
+
+		v, _ := rt.State.Get(state.BrightnessKey(rt.Serial))
+		assert.Equal(t, expect, v)
+	}
+}
+
+func TestCallActionKeepsSecretsLiteral(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	secretFile := path.Join(dir, "token")
+	require.NoError(t, os.WriteFile(secretFile, []byte("pa{{ss}}word\n"), 0o600))
+
+	confFile := path.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(confFile, []byte(`---
+pages:
+  main:
+    keys:
+      0:
+        actions:
+          - type: state
+            attributes:
+              key: token
+              value: "{{ .Page }}:${file.`+secretFile+`}"
+`), 0o600))
+
+	conf, err := config.Load(confFile)
+	require.NoError(t, err)
+
+	rt, _ := newFakeRuntime(t)
+	rt.Page, rt.State = "main", state.New()
+
+	require.NoError(t, CallAction(rt, conf.Pages["main"].Keys[0].Actions[0]))
+
+	v, _ := rt.State.Get("token")
+	assert.Equal(t, "main:pa{{ss}}word", v)
+}
+
+func TestStateDisplayMergesLookOfState(t *testing.T) {
+	t.Parallel()
+
+	fontFile := path.Join(t.TempDir(), "font.ttf")
+	require.NoError(t, os.WriteFile(fontFile, goregular.TTF, 0o600))
+
+	rt, fake := newFakeRuntime(t)
+	rt.Conf.RenderFont, rt.State = fontFile, state.New()
+	require.NoError(t, rt.State.Set("mic", "muted"))
+
+	attrs, err := config.EncodeAttributes(map[string]any{
+		"key":              "mic",
+		"background_color": []int{0x0, 0x0, 0xff, 0xff},
+		"text":             "live",
+		"states": map[string]any{
+			"muted": map[string]any{"text": "muted"},
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, CallDisplayElementOnce(context.Background(), 0, rt, config.KeyDefinition{
+		Display: config.DynamicElement{Type: "state", Attributes: attrs},
+	}))
+
+	// Background of the inline attributes is kept for the state only
+	// changing the text
+	img := fake.KeyImage(0)
+	require.NotNil(t, img)
+	assert.Equal(t, color.RGBA{0x0, 0x0, 0xff, 0xff}, color.RGBAModel.Convert(img.At(img.Bounds().Min.X, img.Bounds().Min.Y)))
+}
+
+func TestAnimationWithoutFramesFails(t *testing.T) {
+	t.Parallel()
+
+	// APNG without frame control chunks: the default image is not part
+	// of the animation, so it has no frames
+	buf := new(bytes.Buffer)
+	require.NoError(t, png.Encode(buf, image.NewRGBA(image.Rect(0, 0, 4, 4))))
+
+	const ihdrEnd = 33 // signature and IHDR chunk
+	actl := []byte{0, 0, 0, 8, 'a', 'c', 'T', 'L', 0, 0, 0, 0, 0, 0, 0, 0}
+	actl = binary.BigEndian.AppendUint32(actl, crc32.ChecksumIEEE(actl[4:]))
+
+	filename := path.Join(t.TempDir(), "empty.png")
+	require.NoError(t, os.WriteFile(filename, slices.Concat(buf.Bytes()[:ihdrEnd], actl, buf.Bytes()[ihdrEnd:]), 0o600))
+
+	rt, _ := newFakeRuntime(t)
+
+	for _, attrs := range []map[string]any{
+		{"path": filename},
+		{"path": filename, "sprite": map[string]any{"frames": 2}},
+	} {
+		encoded, err := config.EncodeAttributes(attrs)
+		require.NoError(t, err)
+
+		kd := config.KeyDefinition{Display: config.DynamicElement{Type: "animation", Attributes: encoded}}
+		assert.Error(t, CallDisplayElementOnce(context.Background(), 0, rt, kd))
+		assert.Error(t, CallDisplayElement(context.Background(), 0, rt, kd))
+	}
+}
@@ -2,21 +2,102 @@
 package opts
 
 import (
+	"fmt"
+	"image"
+	"image/color"
+	"time"
+
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/state"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/templating"
 	"github.com/sashko/go-uinput"
 
 	"github.com/Luzifer/streamdeck/v2"
 )
 
 type (
-	// Runtime contains device handles and callbacks available to modules.
+	// Deck is the part of the StreamDeck client used by modules to
+	// render their content. Besides the streamdeck.Client it is
+	// implemented by wrappers rendering into other surfaces like
+	// regions of the LCD strip.
+	Deck interface {
+		FillColor(keyIdx int, col color.RGBA) error
+		FillImage(keyIdx int, img image.Image) error
+		IconSize() int
+		SetBrightness(pct int) error
+	}
+
+	// SizedDeck is implemented by decks rendering into surfaces not
+	// being square (for example the dial regions of the LCD strip),
+	// display elements render images of the display size instead of
+	// the icon size onto them.
+	SizedDeck interface {
+		Deck
+		DisplaySize() image.Point
+	}
+
+	// Runtime contains device handles and callbacks available to
+	// modules. Conf, Deck and the page toggles refer to the deck the
+	// module is executed for, DeckRuntime gives access to the runtime
+	// of other connected decks by their serial. Background returns the
+	// image to draw the display on (for example the part of the panel
+	// wallpaper of the key), it is nil or returns nil for a black
+	// background. Key, Model, Page and Serial describe where the module
+	// is executed, State is the variable store shared by all decks.
 	Runtime struct {
-		Conf     config.File
-		Deck     *streamdeck.Client
-		Keyboard uinput.Keyboard
+		Background func() image.Image
+		Conf       config.File
+		Deck       Deck
+		Keyboard   uinput.Keyboard
 
+		Key    int
+		Model  string
+		Page   string
+		Serial string
+		State  *state.Store
+
+		DeckRuntime        func(serial string) (Runtime, error)
 		ReloadConfig       func() error
 		TogglePage         func(string) error
 		ToggleRelativePage func(int) error
 	}
 )
+
+var _ Deck = (*streamdeck.Client)(nil)
+
+// DisplaySize returns the size of the images to render onto the deck
+func DisplaySize(deck Deck) image.Point {
+	if sd, ok := deck.(SizedDeck); ok {
+		return sd.DisplaySize()
+	}
+
+	return image.Pt(deck.IconSize(), deck.IconSize())
+}
+
+// DecodeAttributes evaluates the templates within the attributes for
+// the runtime and decodes them into the attributes of a module
+func DecodeAttributes[T any](rt Runtime, atts config.DynamicAttributes) (t T, err error) {
+	if atts, err = templating.Expand(atts, rt.TemplateData()); err != nil {
+		return t, fmt.Errorf("expanding templates: %w", err)
+	}
+
+	return config.DecodeAttributes[T](atts) //nolint:wrapcheck // wraps itself
+}
+
+// TemplateData returns the values available to templates within the
+// attributes of modules executed with the runtime
+func (r Runtime) TemplateData() templating.Data {
+	var values map[string]string
+	if r.State != nil {
+		values = r.State.All()
+	}
+
+	return templating.Data{
+		Key:    r.Key,
+		Model:  r.Model,
+		Page:   r.Page,
+		Serial: r.Serial,
+		State:  values,
+		Time:   time.Now(),
+	}
+}
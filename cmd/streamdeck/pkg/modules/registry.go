@@ -6,25 +6,31 @@ import (
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/actions/keypress"
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/actions/page"
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/actions/reload"
+	stateaction "github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/actions/state"
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/actions/toggledisplay"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/displays/animation"
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/displays/color"
 	execdisplay "github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/displays/exec"
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/displays/httpdisplay"
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/displays/image"
+	statedisplay "github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/displays/state"
 	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/displays/text"
 )
 
 func init() {
-	registerAction("exec", execaction.Action{})
-	registerAction("http", httpaction.Action{})
-	registerAction("key_press", keypress.Action{})
-	registerAction("page", page.Action{})
-	registerAction("reload_config", reload.Action{})
-	registerAction("toggle_display", toggledisplay.Action{})
+	registerAction("exec", execaction.Action{}, execaction.Attrs{})
+	registerAction("http", httpaction.Action{}, httpaction.Attrs{})
+	registerAction("key_press", keypress.Action{}, keypress.Attrs{})
+	registerAction("page", page.Action{}, page.Attrs{})
+	registerAction("reload_config", reload.Action{}, nil)
+	registerAction("state", stateaction.Action{}, stateaction.Attrs{})
+	registerAction("toggle_display", toggledisplay.Action{}, nil)
 
-	registerDisplayElement("color", color.Display{})
-	registerDisplayElement("exec", &execdisplay.Display{})
-	registerDisplayElement("http", &httpdisplay.Display{})
-	registerDisplayElement("text", text.Display{})
-	registerDisplayElement("image", image.Display{})
+	registerDisplayElement("animation", animation.Display{}, animation.Attrs{})
+	registerDisplayElement("color", color.Display{}, color.Attrs{})
+	registerDisplayElement("exec", &execdisplay.Display{}, execdisplay.Attrs{})
+	registerDisplayElement("http", &httpdisplay.Display{}, httpdisplay.Attrs{})
+	registerDisplayElement("text", text.Display{}, text.Attrs{})
+	registerDisplayElement("image", image.Display{}, image.Attrs{})
+	registerDisplayElement("state", statedisplay.Display{}, statedisplay.Attrs{})
 }
@@ -0,0 +1,92 @@
+package modules
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/schema"
+)
+
+var (
+	dynamicElementType = reflect.TypeFor[config.DynamicElement]()
+	templatesType      = reflect.TypeFor[map[string]config.KeyDefinition]()
+)
+
+// ConfigSchema returns the JSON Schema of the configuration file. The
+// attributes of actions and display elements are described by the
+// attributes published by the registered modules and selected by the
+// type of the element.
+func ConfigSchema() schema.Schema {
+	g := schema.New(elementField)
+
+	registeredActionsLock.Lock()
+	g.Define("action", elementSchema(g, "action", registeredActionAttributes))
+	registeredActionsLock.Unlock()
+
+	registeredDisplayElementsLock.Lock()
+	g.Define("display", elementSchema(g, "display", registeredDisplayAttributes))
+	registeredDisplayElementsLock.Unlock()
+
+	return g.Document(reflect.TypeFor[config.File](), "StreamDeck configuration")
+}
+
+// elementField references the action or display definition for the
+// dynamic elements of the configuration. Templates are not described
+// further as their parameters may take the place of any value.
+func elementField(field reflect.StructField) (schema.Schema, bool) {
+	switch {
+	case field.Type == templatesType && field.Name == "Templates":
+		return schema.Schema{"type": "object", "additionalProperties": schema.Schema{"type": "object"}}, true
+
+	case field.Type == dynamicElementType && (field.Name == "Display" || field.Name == "InfoBar"):
+		return schema.Ref("display"), true
+
+	case field.Type == dynamicElementType:
+		return schema.Ref("action"), true
+
+	case field.Type == reflect.SliceOf(dynamicElementType):
+		return schema.Schema{"type": "array", "items": schema.Ref("action")}, true
+	}
+
+	return nil, false
+}
+
+// elementSchema describes a dynamic element of the given kind: its
+// type is one of the registered types and the attributes are checked
+// against the attributes of the module selected by the type
+func elementSchema(g *schema.Generator, kind string, attributes map[string]reflect.Type) schema.Schema {
+	var (
+		names = slices.Sorted(maps.Keys(attributes))
+		cases = make([]any, 0, len(names))
+	)
+
+	for _, name := range names {
+		attrs := schema.Schema{"type": []string{"object", "null"}, "maxProperties": 0}
+		if t := attributes[name]; t != nil {
+			attrs = g.Define(kind+"."+name, g.Object(t))
+		}
+
+		cases = append(cases, schema.Schema{
+			"if": schema.Schema{
+				"properties": schema.Schema{"type": schema.Schema{"const": name}},
+				"required":   []string{"type"},
+			},
+			"then": schema.Schema{
+				"properties": schema.Schema{"attributes": attrs},
+			},
+		})
+	}
+
+	return schema.Schema{
+		"type": "object",
+		"properties": schema.Schema{
+			"type":       schema.Schema{"type": "string", "enum": names},
+			"long_press": schema.Schema{"type": "boolean"},
+			"attributes": schema.Schema{"type": []string{"object", "null"}},
+		},
+		"additionalProperties": false,
+		"allOf":                cases,
+	}
+}
@@ -0,0 +1,45 @@
+package modules
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSchema(t *testing.T) {
+	t.Parallel()
+
+	doc := ConfigSchema()
+
+	_, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	defs := doc["definitions"].(schema.Schema) //nolint:forcetypeassert // fails the test anyway
+	for name := range registeredActions {
+		assert.Contains(t, defs["action"].(schema.Schema)["properties"].(schema.Schema)["type"].(schema.Schema)["enum"], name) //nolint:forcetypeassert // fails the test anyway
+	}
+
+	for name, attrs := range registeredDisplayAttributes {
+		if attrs != nil {
+			assert.Contains(t, defs, "display."+name)
+		}
+	}
+
+	// Exec display publishes the inlined text attributes
+	execProps := defs["display.exec"].(schema.Schema)["properties"].(schema.Schema) //nolint:forcetypeassert // fails the test anyway
+	assert.Contains(t, execProps, "command")
+	assert.Contains(t, execProps, "text")
+
+	key := defs["config.KeyDefinition"].(schema.Schema)["properties"].(schema.Schema) //nolint:forcetypeassert // fails the test anyway
+	assert.Equal(t, schema.Ref("display"), key["display"])
+	assert.Equal(t, schema.Schema{"type": "array", "items": schema.Ref("action")}, key["actions"])
+	assert.Equal(t, schema.Schema{"type": "object"}, doc["properties"].(schema.Schema)["templates"].(schema.Schema)["additionalProperties"]) //nolint:forcetypeassert // fails the test anyway
+
+	assert.Equal(t, schema.Schema{
+		"type": "string",
+		"enum": []string{"", "bottom", "top"},
+	}, doc["properties"].(schema.Schema)["caption_position"]) //nolint:forcetypeassert // fails the test anyway
+}
@@ -0,0 +1,145 @@
+package modules
+
+import (
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/actions/page"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/displays/animation"
+	execdisplay "github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/displays/exec"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/displays/httpdisplay"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/displays/image"
+	statedisplay "github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/displays/state"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/displays/text"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/templating"
+	"go.yaml.in/yaml/v3"
+)
+
+// ValidateElement checks the type of an action or display element
+// found in the configuration is registered, its attributes decode into
+// the attributes of the module and the pages and files referenced by
+// the attributes exist.
+func ValidateElement(ref config.ElementRef) []config.Problem {
+	var (
+		typeNode  = config.MappingValue(ref.Node, "type")
+		attrsNode = config.MappingValue(ref.Node, "attributes")
+
+		kind       = "action"
+		registry   = registeredActions
+		attributes = registeredActionAttributes
+	)
+
+	if ref.Display {
+		kind, registry, attributes = "display", registeredDisplayElements, registeredDisplayAttributes
+	}
+
+	if ref.Element.Type == "" {
+		if !isEmptyNode(attrsNode) {
+			return []config.Problem{config.NewProblem(ref.Node, "%s has attributes but no type", kind)}
+		}
+		return nil
+	}
+
+	if _, ok := registry[ref.Element.Type]; !ok {
+		return []config.Problem{config.NewProblem(typeNode, "unknown %s type %q", kind, ref.Element.Type)}
+	}
+
+	attrsType := attributes[ref.Element.Type]
+	if attrsType == nil {
+		if !isEmptyNode(attrsNode) {
+			return []config.Problem{config.NewProblem(attrsNode, "%s type %q has no attributes", kind, ref.Element.Type)}
+		}
+		return nil
+	}
+
+	if isEmptyNode(attrsNode) {
+		return nil
+	}
+
+	if problems := config.CheckNode(attrsNode, reflect.New(attrsType).Elem().Interface()); len(problems) > 0 {
+		return problems
+	}
+
+	if problems := checkTemplates(attrsNode); len(problems) > 0 {
+		return problems
+	}
+
+	attrs := reflect.New(attrsType)
+	if err := attrsNode.Decode(attrs.Interface()); err != nil {
+		return []config.Problem{config.NewProblem(attrsNode, "decoding attributes: %s", err)}
+	}
+
+	return checkAttributeReferences(ref, attrsNode, attrs.Interface())
+}
+
+// checkAttributeReferences reports pages and files referenced by the
+// attributes of the element which do not exist
+func checkAttributeReferences(ref config.ElementRef, node *yaml.Node, attrs any) (problems []config.Problem) {
+	checkFileIn := func(node *yaml.Node, field, filename string) {
+		if filename == "" || templating.IsTemplate(filename) {
+			return
+		}
+
+		if _, err := os.Stat(filename); err != nil {
+			problems = append(problems, config.NewProblem(config.MappingValue(node, field), "file %q not found", filename))
+		}
+	}
+	checkFile := func(field, filename string) { checkFileIn(node, field, filename) }
+
+	switch a := attrs.(type) {
+	case *page.Attrs:
+		if a.Name != "" && !templating.IsTemplate(a.Name+a.Deck) && !ref.HasPage(a.Deck, a.Name) {
+			problems = append(problems, config.NewProblem(config.MappingValue(node, "name"), "page %q is not defined", a.Name))
+		}
+
+	case *animation.Attrs:
+		checkFile("path", a.Path)
+
+	case *image.Attrs:
+		checkFile("path", a.Path)
+
+	case *text.Attrs:
+		checkFile("image", a.Image)
+
+	case *execdisplay.Attrs:
+		checkFile("image", a.Image)
+
+	case *httpdisplay.Attrs:
+		checkFile("image", a.Image)
+
+	case *statedisplay.Attrs:
+		checkFile("image", a.Image)
+
+		states := config.MappingValue(node, "states")
+		for _, value := range slices.Sorted(maps.Keys(a.States)) {
+			checkFileIn(config.MappingValue(states, value), "image", a.States[value].Image)
+		}
+	}
+
+	return problems
+}
+
+// checkTemplates reports templates within the attributes failing to
+// parse, they are evaluated when executing or rendering the element
+func checkTemplates(node *yaml.Node) (problems []config.Problem) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" && templating.IsTemplate(node.Value) {
+		if err := templating.Check(node.Value); err != nil {
+			problems = append(problems, config.NewProblem(node, "invalid template: %s", err))
+		}
+	}
+
+	for _, child := range node.Content {
+		problems = append(problems, checkTemplates(child)...)
+	}
+
+	return problems
+}
+
+func isEmptyNode(node *yaml.Node) bool {
+	return node == nil ||
+		(node.Kind == yaml.ScalarNode && node.Tag == "!!null") ||
+		(node.Kind == yaml.MappingNode && len(node.Content) == 0)
+}
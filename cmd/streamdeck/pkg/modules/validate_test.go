@@ -0,0 +1,101 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v3"
+)
+
+func elementRef(t *testing.T, display bool, src string) config.ElementRef {
+	t.Helper()
+
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(src), &doc))
+
+	ref := config.ElementRef{
+		Display: display,
+		Node:    doc.Content[0],
+		HasPage: func(serial, name string) bool { return serial == "" && name == "main" },
+	}
+	require.NoError(t, ref.Node.Decode(&ref.Element))
+
+	return ref
+}
+
+func TestValidateElement(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		display  bool
+		src      string
+		problems []string
+	}{
+		"valid display": {
+			display: true,
+			src:     "type: text\nattributes:\n  text: hi\n  font_size: 20",
+		},
+		"valid inline attributes": {
+			display: true,
+			src:     "type: exec\nattributes:\n  command: [date]\n  text: hi",
+		},
+		"unknown type": {
+			src:      "type: dance",
+			problems: []string{`line 1 column 7: unknown action type "dance"`},
+		},
+		"display used as action": {
+			src:      "type: text",
+			problems: []string{`line 1 column 7: unknown action type "text"`},
+		},
+		"bad attributes": {
+			display: true,
+			src:     "type: color\nattributes:\n  rgba: red\n  colour: red",
+			problems: []string{
+				`line 3 column 9: expected sequence, found value "red"`,
+				`line 4 column 3: unknown field "colour"`,
+			},
+		},
+		"missing page": {
+			src:      "type: page\nattributes:\n  name: other",
+			problems: []string{`line 3 column 9: page "other" is not defined`},
+		},
+		"known page": {
+			src: "type: page\nattributes:\n  name: main",
+		},
+		"missing file": {
+			display:  true,
+			src:      "type: image\nattributes:\n  path: /does/not/exist.png",
+			problems: []string{`line 3 column 9: file "/does/not/exist.png" not found`},
+		},
+		"missing state file": {
+			display:  true,
+			src:      "type: state\nattributes:\n  key: mic\n  states:\n    muted:\n      image: /does/not/exist.png",
+			problems: []string{`line 6 column 14: file "/does/not/exist.png" not found`},
+		},
+		"templated references": {
+			display: true,
+			src:     "type: image\nattributes:\n  path: \"/icons/{{ .Page }}.png\"",
+		},
+		"invalid template": {
+			src:      "type: page\nattributes:\n  name: \"{{ .Page \"",
+			problems: []string{`line 3 column 9: invalid template: parsing template: template: attribute:1: unclosed action`},
+		},
+		"attributes without type": {
+			src:      "attributes:\n  name: main",
+			problems: []string{`line 1 column 1: action has attributes but no type`},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var problems []string
+			for _, p := range ValidateElement(elementRef(t, tc.display, tc.src)) {
+				problems = append(problems, p.String())
+			}
+
+			assert.Equal(t, tc.problems, problems)
+		})
+	}
+}
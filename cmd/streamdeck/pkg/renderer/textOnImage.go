@@ -36,33 +36,47 @@ type (
 	TextOnImageRenderer struct {
 		devs opts.Runtime
 		img  draw.Image
+		size image.Point
 	}
 )
 
-// NewTextOnImageRenderer creates a renderer for the current StreamDeck key size.
+// NewTextOnImageRenderer creates a renderer for the display size of
+// the deck starting with the runtime background or black.
 func NewTextOnImageRenderer(devs opts.Runtime) *TextOnImageRenderer {
-	// Create new black image in icon size
-	var img draw.Image = image.NewRGBA(image.Rect(0, 0, devs.Deck.IconSize(), devs.Deck.IconSize()))
+	size := opts.DisplaySize(devs.Deck)
+
+	// Create new black image in display size
+	var img draw.Image = image.NewRGBA(image.Rectangle{Max: size})
 	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{0x0, 0x0, 0x0, 0xff}), image.Point{}, draw.Src)
 
+	if devs.Background != nil {
+		if bg := devs.Background(); bg != nil {
+			if bg.Bounds().Size() != img.Bounds().Size() {
+				bg = helpers.FitImage(bg, size)
+			}
+			draw.Draw(img, img.Bounds(), bg, bg.Bounds().Min, draw.Over)
+		}
+	}
+
 	return &TextOnImageRenderer{
 		devs: devs,
 		img:  img,
+		size: size,
 	}
 }
 
 // DrawBackground draws an image as the key background.
 func (t *TextOnImageRenderer) DrawBackground(bgi image.Image) {
-	bgi = helpers.AutoSizeImage(bgi, t.devs.Deck.IconSize())
+	bgi = helpers.FitImage(bgi, t.size)
 	draw.Draw(t.img, t.img.Bounds(), bgi, image.Point{}, draw.Src)
 }
 
 // DrawBackgroundColor fills the key background with a color.
 func (t *TextOnImageRenderer) DrawBackgroundColor(col color.RGBA) {
-	img := image.NewRGBA(image.Rect(0, 0, t.devs.Deck.IconSize(), t.devs.Deck.IconSize()))
+	img := image.NewRGBA(image.Rectangle{Max: t.size})
 
-	for x := 0; x < t.devs.Deck.IconSize(); x++ {
-		for y := 0; y < t.devs.Deck.IconSize(); y++ {
+	for x := 0; x < t.size.X; x++ {
+		for y := 0; y < t.size.Y; y++ {
 			img.Set(x, y, col)
 		}
 	}
@@ -139,6 +153,14 @@ func (t *TextOnImageRenderer) DrawCaptionText(text string) error {
 	return t.drawText(c, text, textColor, t.devs.Conf.CaptionFontSize, t.devs.Conf.CaptionBorder, anchor)
 }
 
+// DrawImage draws an image scaled to the key size on top of the
+// current content keeping the background visible through transparent
+// parts of the image.
+func (t *TextOnImageRenderer) DrawImage(img image.Image) {
+	img = helpers.FitImage(img, t.size)
+	draw.Draw(t.img, t.img.Bounds(), img, img.Bounds().Min, draw.Over)
+}
+
 // GetImage returns the rendered key image.
 func (t TextOnImageRenderer) GetImage() image.Image { return t.img }
 
@@ -161,7 +183,7 @@ func (t *TextOnImageRenderer) drawText(c *freetype.Context, text string, textCol
 			}
 		}
 
-		if int(float64(maxX)/64) > t.devs.Deck.IconSize()-2*border || (int(c.PointToFixed(fontsize)/64))*len(textLines)+(len(textLines)-1)*2 > t.devs.Deck.IconSize()-2*border {
+		if int(float64(maxX)/64) > t.size.X-2*border || (int(c.PointToFixed(fontsize)/64))*len(textLines)+(len(textLines)-1)*2 > t.size.Y-2*border {
 			fontsize -= 2
 			continue
 		}
@@ -178,9 +200,9 @@ func (t *TextOnImageRenderer) drawText(c *freetype.Context, text string, textCol
 	case textDrawAnchorTop:
 		yLineTop = border
 	case textDrawAnchorCenter:
-		yLineTop = int(float64(t.devs.Deck.IconSize())/2.0 - float64(yTotal)/2.0)
+		yLineTop = int(float64(t.size.Y)/2.0 - float64(yTotal)/2.0)
 	case textDrawAnchorBottom:
-		yLineTop = t.devs.Deck.IconSize() - yTotal - border
+		yLineTop = t.size.Y - yTotal - border
 	}
 
 	for _, tl := range textLines {
@@ -192,7 +214,7 @@ func (t *TextOnImageRenderer) drawText(c *freetype.Context, text string, textCol
 
 		c.SetSrc(image.NewUniform(textColor))
 
-		xcenter := (float64(t.devs.Deck.IconSize()-2*border) / 2.0) - (float64(int(float64(ext.X)/64)) / 2.0) + float64(border)
+		xcenter := (float64(t.size.X-2*border) / 2.0) - (float64(int(float64(ext.X)/64)) / 2.0) + float64(border)
 		ylower := yLineTop + int(c.PointToFixed(fontsize)/64)
 
 		if _, err = c.DrawString(tl, freetype.Pt(int(xcenter), ylower)); err != nil {
@@ -0,0 +1,207 @@
+// Package schema generates JSON Schemas from the Go types used to
+// decode the configuration.
+package schema
+
+import (
+	"maps"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Draft is the JSON Schema version of the generated schemas, it is
+// the latest version fully supported by the yaml-language-server
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+// durationPattern matches the durations accepted by time.ParseDuration
+const durationPattern = `^-?([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+type (
+	// Schema is a JSON Schema object
+	Schema = map[string]any
+
+	// Generator converts Go types into schemas. Named struct types are
+	// added as definitions and referenced to keep the schema small.
+	Generator struct {
+		definitions Schema
+		names       map[reflect.Type]string
+		// override returns the schema to use for the field instead of
+		// generating it from the type of the field
+		override func(field reflect.StructField) (Schema, bool)
+	}
+
+	// Provider is implemented by types publishing their own schema
+	// instead of the one generated from their kind (for example to
+	// restrict a string to its known values)
+	Provider interface {
+		JSONSchema() Schema
+	}
+)
+
+var providerType = reflect.TypeFor[Provider]()
+
+// New creates a Generator, override may be nil or return the schema
+// for fields needing more than their type tells (for example untyped
+// attributes)
+func New(override func(field reflect.StructField) (Schema, bool)) *Generator {
+	return &Generator{
+		definitions: make(Schema),
+		names:       make(map[reflect.Type]string),
+		override:    override,
+	}
+}
+
+// Define adds a schema to the definitions and returns a reference to it
+func (g *Generator) Define(name string, s Schema) Schema {
+	g.definitions[name] = s
+	return Ref(name)
+}
+
+// Document creates the schema document for the root type including all
+// definitions added before or while generating the root type
+func (g *Generator) Document(root reflect.Type, title string) Schema {
+	doc := g.Type(root)
+	if ref, ok := doc["$ref"].(string); ok {
+		// Inline the root type
+		name := strings.TrimPrefix(ref, "#/definitions/")
+		doc = maps.Clone(g.definitions[name].(Schema)) //nolint:forcetypeassert // only schemas are defined
+		delete(g.definitions, name)
+	}
+
+	doc["$schema"] = Draft
+	doc["title"] = title
+	doc["definitions"] = g.definitions
+
+	return doc
+}
+
+// Type returns the schema of the type
+//
+//nolint:gocyclo // maps all kinds in one place
+func (g *Generator) Type(t reflect.Type) Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t.Implements(providerType):
+		return reflect.Zero(t).Interface().(Provider).JSONSchema() //nolint:forcetypeassert // checked above
+
+	case t == reflect.TypeFor[time.Duration]():
+		return Schema{"type": "string", "pattern": durationPattern}
+
+	case t == reflect.TypeFor[time.Time]():
+		return Schema{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Schema{"type": "integer"}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer", "minimum": 0}
+
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+
+	case reflect.String:
+		return Schema{"type": "string"}
+
+	case reflect.Slice:
+		return Schema{"type": "array", "items": g.Type(t.Elem())}
+
+	case reflect.Array:
+		return Schema{"type": "array", "items": g.Type(t.Elem()), "maxItems": t.Len()}
+
+	case reflect.Map:
+		s := Schema{"type": "object", "additionalProperties": g.Type(t.Elem())}
+		if k := g.Type(t.Key()); k["type"] == "integer" {
+			s["propertyNames"] = Schema{"pattern": "^-?[0-9]+$"}
+		}
+		return s
+
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.Object(t)
+		}
+
+		name, ok := g.names[t]
+		if !ok {
+			name = g.definitionName(t)
+			// Reserve the name for recursive types
+			g.names[t] = name
+			g.definitions[name] = Schema{}
+			g.definitions[name] = g.Object(t)
+		}
+		return Ref(name)
+
+	default:
+		// Interfaces and other dynamic values
+		return Schema{}
+	}
+}
+
+// Object returns the schema of the struct type without adding it to
+// the definitions. Unknown fields are not allowed as they are rejected
+// when loading the configuration.
+func (g *Generator) Object(t reflect.Type) Schema {
+	properties := make(Schema)
+	g.addFields(properties, t)
+
+	return Schema{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// definitionName returns an unused name for the struct type qualified
+// by its package as modules tend to reuse the same type names
+func (g *Generator) definitionName(t reflect.Type) string {
+	base := path.Base(t.PkgPath()) + "." + t.Name()
+	name := base
+	for i := 2; g.definitions[name] != nil; i++ { //revive:disable-line:add-constant // first suffix
+		name = base + strconv.Itoa(i)
+	}
+
+	return name
+}
+
+// addFields adds the properties for the fields of the struct by their
+// YAML names including fields of inlined structs
+func (g *Generator) addFields(properties Schema, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		switch {
+		case name == "-":
+			continue
+		case strings.Contains(opts, "inline") && field.Type.Kind() == reflect.Struct:
+			g.addFields(properties, field.Type)
+			continue
+		case name == "":
+			name = strings.ToLower(field.Name)
+		}
+
+		if g.override != nil {
+			if s, ok := g.override(field); ok {
+				properties[name] = s
+				continue
+			}
+		}
+
+		properties[name] = g.Type(field.Type)
+	}
+}
+
+// Ref returns a reference to the named definition
+func Ref(name string) Schema { return Schema{"$ref": "#/definitions/" + name} }
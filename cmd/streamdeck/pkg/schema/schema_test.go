@@ -0,0 +1,92 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	testColor string
+
+	testInner struct {
+		Caption string `yaml:"caption"`
+	}
+
+	testOuter struct {
+		Color    testColor            `yaml:"color"`
+		Delay    time.Duration        `yaml:"delay"`
+		Keys     map[int]testInner    `yaml:"keys"`
+		Limits   [2]uint              `yaml:"limits"`
+		Names    []string             `yaml:"names"`
+		Next     *testOuter           `yaml:"next"`
+		Skipped  string               `yaml:"-"`
+		Untagged float64              //nolint:tagliatelle // testing name fallback
+		Values   map[string]testInner `yaml:"values"`
+
+		testInner `yaml:",inline"` //nolint:unused // read through reflection
+
+		hidden bool //nolint:unused // must not be published
+	}
+)
+
+func (testColor) JSONSchema() Schema { return Schema{"enum": []string{"red", "green"}} }
+
+func TestType(t *testing.T) {
+	t.Parallel()
+
+	g := New(nil)
+	assert.Equal(t, Ref("schema.testOuter"), g.Type(reflect.TypeFor[*testOuter]()))
+
+	outer := g.definitions["schema.testOuter"].(Schema) //nolint:forcetypeassert // fails the test anyway
+	assert.Equal(t, false, outer["additionalProperties"])
+	assert.Equal(t, Schema{
+		"caption":  Schema{"type": "string"},
+		"color":    Schema{"enum": []string{"red", "green"}},
+		"delay":    Schema{"type": "string", "pattern": durationPattern},
+		"keys":     Schema{"type": "object", "additionalProperties": Ref("schema.testInner"), "propertyNames": Schema{"pattern": "^-?[0-9]+$"}},
+		"limits":   Schema{"type": "array", "items": Schema{"type": "integer", "minimum": 0}, "maxItems": 2},
+		"names":    Schema{"type": "array", "items": Schema{"type": "string"}},
+		"next":     Ref("schema.testOuter"),
+		"untagged": Schema{"type": "number"},
+		"values":   Schema{"type": "object", "additionalProperties": Ref("schema.testInner")},
+	}, outer["properties"])
+
+	assert.Contains(t, g.definitions, "schema.testInner")
+}
+
+func TestOverrideAndDocument(t *testing.T) {
+	t.Parallel()
+
+	g := New(func(field reflect.StructField) (Schema, bool) {
+		if field.Name == "Names" {
+			return Ref("names"), true
+		}
+		return nil, false
+	})
+
+	g.Define("names", Schema{"type": "array"})
+	doc := g.Document(reflect.TypeFor[testInner](), "Test")
+
+	assert.Equal(t, Draft, doc["$schema"])
+	assert.Equal(t, "Test", doc["title"])
+	assert.Equal(t, Schema{"caption": Schema{"type": "string"}}, doc["properties"])
+	assert.Equal(t, Schema{"names": Schema{"type": "array"}}, doc["definitions"])
+
+	props := g.Object(reflect.TypeFor[testOuter]())["properties"].(Schema) //nolint:forcetypeassert // fails the test anyway
+	assert.Equal(t, Ref("names"), props["names"])
+}
+
+func TestDefinitionNameCollision(t *testing.T) {
+	t.Parallel()
+
+	g := New(nil)
+	require.Equal(t, Ref("schema.testInner"), g.Type(reflect.TypeFor[testInner]()))
+
+	type testInner struct{}
+	assert.Equal(t, Ref("schema.testInner2"), g.Type(reflect.TypeFor[testInner]()))
+	assert.Equal(t, Ref("schema.testInner2"), g.Type(reflect.TypeFor[testInner]()))
+}
@@ -0,0 +1,30 @@
+package secrets
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogHook redacts the secrets resolved from the configuration from the
+// message and fields of all log entries
+type LogHook struct{}
+
+var _ logrus.Hook = LogHook{}
+
+// Fire redacts the entry, it is a copy of the entry being logged
+func (LogHook) Fire(entry *logrus.Entry) error {
+	entry.Message = Redact(entry.Message)
+
+	for k, v := range entry.Data {
+		s := fmt.Sprint(v)
+		if r := Redact(s); r != s {
+			entry.Data[k] = r
+		}
+	}
+
+	return nil
+}
+
+// Levels returns all levels as secrets must not be logged on any level
+func (LogHook) Levels() []logrus.Level { return logrus.AllLevels }
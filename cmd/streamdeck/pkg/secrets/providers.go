@@ -0,0 +1,82 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// envNamePattern matches the names of environment variables which can
+// be referenced, other references are kept as they are
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func init() {
+	Register("cmd", ProviderFunc(resolveCommand), true)
+	Register("env", ProviderFunc(resolveEnv), false)
+	Register("file", ProviderFunc(resolveFile), true)
+	Register("keyring", ProviderFunc(resolveKeyring), true)
+}
+
+// resolveCommand runs the reference as shell command and returns its
+// output without trailing line breaks
+func resolveCommand(ref string) (string, error) {
+	return runCommand(exec.Command("/bin/sh", "-c", ref)) //#nosec:G204 // intended to run user-defined command
+}
+
+// resolveEnv returns the value of the environment variable
+func resolveEnv(ref string) (string, error) {
+	if !envNamePattern.MatchString(ref) {
+		return "", ErrNoReference
+	}
+
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %q is not set", ref)
+	}
+
+	return value, nil
+}
+
+// resolveFile returns the content of the file without trailing line
+// breaks
+func resolveFile(ref string) (string, error) {
+	content, err := os.ReadFile(ref) //#nosec:G304 // intended to read user-defined file
+	if err != nil {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// resolveKeyring looks up the secret from the secret service of the
+// desktop session (GNOME Keyring, KWallet) by its service and optional
+// username given as "service" or "service/username"
+func resolveKeyring(ref string) (string, error) {
+	service, username, hasUser := strings.Cut(ref, "/")
+
+	args := []string{"lookup", "service", service}
+	if hasUser {
+		args = append(args, "username", username)
+	}
+
+	return runCommand(exec.Command("secret-tool", args...))
+}
+
+func runCommand(cmd *exec.Cmd) (string, error) {
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = errors.Join(err, errors.New(msg))
+		}
+		return "", fmt.Errorf("running %s: %w", cmd.Args[0], err)
+	}
+
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
@@ -0,0 +1,158 @@
+// Package secrets resolves references like ${file./run/secrets/token}
+// within the configuration through pluggable providers and keeps the
+// resolved secrets out of logs and reports.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// minRedactLength is the length of the shortest secret being
+	// redacted, shorter values (like "on" or a PIN) would mangle
+	// unrelated text
+	minRedactLength = 6
+
+	// redacted replaces secrets in logs and reports
+	redacted = "[redacted]"
+)
+
+type (
+	// Provider resolves the references of one kind, the reference is
+	// the part between the provider name and the closing brace
+	Provider interface {
+		Resolve(ref string) (string, error)
+	}
+
+	// ProviderFunc implements Provider with a function
+	ProviderFunc func(ref string) (string, error)
+
+	registration struct {
+		provider Provider
+		secret   bool
+	}
+)
+
+// ErrNoReference is returned by providers for references they do not
+// handle, these references are kept as they are
+var ErrNoReference = errors.New("not a reference")
+
+var (
+	referencePattern = regexp.MustCompile(`\$\{([a-z]+)\.([^}]+)\}`)
+
+	providers     = make(map[string]registration)
+	providersLock sync.RWMutex
+
+	known     = make(map[string]struct{})
+	knownLock sync.RWMutex
+)
+
+// Resolve calls the function
+func (p ProviderFunc) Resolve(ref string) (string, error) { return p(ref) }
+
+// Register adds a provider for references starting with the name.
+// Values resolved by providers marked as secret are redacted from logs
+// and reports.
+func Register(name string, p Provider, secret bool) {
+	providersLock.Lock()
+	defer providersLock.Unlock()
+
+	providers[name] = registration{provider: p, secret: secret}
+}
+
+// Expand replaces all references of registered providers within the
+// value. References of unknown providers are kept as they might be
+// resolved later (for example template parameters), so are references
+// the provider does not handle (see ErrNoReference).
+func Expand(value string) (string, error) {
+	return ExpandQuoted(value, nil)
+}
+
+// ExpandQuoted works like Expand but passes the resolved values through
+// quote (if not nil) before substituting them into the value, for
+// example to keep them literal within templates
+func ExpandQuoted(value string, quote func(string) string) (string, error) {
+	var err error
+
+	expanded := referencePattern.ReplaceAllStringFunc(value, func(match string) string {
+		if err != nil {
+			return match
+		}
+
+		parts := referencePattern.FindStringSubmatch(match)
+
+		providersLock.RLock()
+		reg, ok := providers[parts[1]]
+		providersLock.RUnlock()
+
+		if !ok {
+			return match
+		}
+
+		resolved, rErr := reg.provider.Resolve(parts[2])
+		switch {
+		case errors.Is(rErr, ErrNoReference):
+			return match
+
+		case rErr != nil:
+			err = fmt.Errorf("resolving %s reference: %w", parts[1], rErr)
+			return match
+		}
+
+		if reg.secret {
+			remember(resolved)
+		}
+
+		if quote != nil {
+			return quote(resolved)
+		}
+
+		return resolved
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return expanded, nil
+}
+
+// Redact replaces all secrets resolved so far within the text,
+// secrets shorter than six characters are not redacted
+func Redact(text string) string {
+	knownLock.RLock()
+	defer knownLock.RUnlock()
+
+	if len(known) == 0 {
+		return text
+	}
+
+	values := make([]string, 0, len(known))
+	for v := range known {
+		values = append(values, v)
+	}
+
+	// Replace longer secrets first as they might contain shorter ones
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	for _, v := range values {
+		text = strings.ReplaceAll(text, v, redacted)
+	}
+
+	return text
+}
+
+func remember(secret string) {
+	if len(secret) < minRedactLength {
+		return
+	}
+
+	knownLock.Lock()
+	defer knownLock.Unlock()
+
+	known[secret] = struct{}{}
+}
@@ -0,0 +1,75 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	filename := path.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(filename, []byte("file-secret-4711\n"), 0o600))
+
+	Register("test", ProviderFunc(func(ref string) (string, error) { return "<" + ref + ">", nil }), false)
+
+	for value, expected := range map[string]string{
+		"Bearer ${file." + filename + "}":   "Bearer file-secret-4711",
+		"${cmd.printf 'cmd-%s' secret-42}":  "cmd-secret-42",
+		"${test.a b}-${test.c}":             "<a b>-<c>",
+		"${param.name} stays for templates": "${param.name} stays for templates",
+		"${env.FOO-BAR} is no variable":     "${env.FOO-BAR} is no variable",
+		"plain":                             "plain",
+	} {
+		expanded, err := Expand(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, expanded, value)
+	}
+
+	for _, value := range []string{
+		"${file./does/not/exist}",
+		"${cmd.exit 1}",
+	} {
+		_, err := Expand(value)
+		assert.Error(t, err, value)
+	}
+
+	quoted, err := ExpandQuoted("x ${test.a} ${param.b}", func(v string) string { return "'" + v + "'" })
+	require.NoError(t, err)
+	assert.Equal(t, "x '<a>' ${param.b}", quoted)
+
+	assert.Equal(t, "token [redacted] and [redacted], <c> is public", Redact("token file-secret-4711 and cmd-secret-42, <c> is public"))
+
+	// Short secrets would mangle unrelated text
+	_, err = Expand("${cmd.echo on}")
+	require.NoError(t, err)
+	assert.Equal(t, "switched on", Redact("switched on"))
+}
+
+func TestLogHook(t *testing.T) {
+	t.Parallel()
+
+	_, err := Expand("${cmd.echo hook-secret-0815}")
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	logger.AddHook(LogHook{})
+
+	logger.WithError(errors.New("calling https://example.com/?token=hook-secret-0815")).
+		WithField("header", "Bearer hook-secret-0815").
+		WithField("count", 3).
+		Error("request with hook-secret-0815 failed")
+
+	assert.NotContains(t, buf.String(), "hook-secret-0815")
+	assert.Contains(t, buf.String(), "token=[redacted]")
+	assert.Contains(t, buf.String(), "count=3")
+}
@@ -0,0 +1,295 @@
+// Package simulator serves a web page showing a simulated StreamDeck
+// and forwards clicks on its keys as key events to the deck.
+package simulator
+
+import (
+	"bytes"
+	"context"
+	_ "embed" // Required to embed the web page
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Luzifer/streamdeck/v2"
+)
+
+const (
+	// updateInterval is the interval to check the deck for changed
+	// images to send to the page
+	updateInterval = 40 * time.Millisecond
+
+	writeTimeout = time.Second
+)
+
+// Collection of message types exchanged with the page
+const (
+	msgTypeDown   = "down"
+	msgTypeKey    = "key"
+	msgTypeLayout = "layout"
+	msgTypeScreen = "screen"
+	msgTypeUp     = "up"
+)
+
+type (
+	// Server serves the web page for a simulated deck. The client must
+	// use the FakeDeck as its transport.
+	Server struct {
+		client *streamdeck.Client
+		deck   *streamdeck.FakeDeck
+		mux    *http.ServeMux
+
+		upgrader websocket.Upgrader
+	}
+
+	// message is sent to and received from the page. Images are sent
+	// as data-URLs, touch keys without display get their LED color.
+	message struct {
+		Type string `json:"type"`
+
+		Key   int    `json:"key"`
+		Color string `json:"color,omitempty"`
+		Image string `json:"image,omitempty"`
+
+		Columns   int         `json:"columns,omitempty"`
+		IconSize  int         `json:"icon_size,omitempty"`
+		Keys      int         `json:"keys,omitempty"`
+		Model     string      `json:"model,omitempty"`
+		Screen    image.Point `json:"screen,omitzero"`
+		TouchKeys int         `json:"touch_keys,omitempty"`
+	}
+
+	// pageState contains what was sent to a page to only send changes
+	pageState struct {
+		keys       map[int]image.Image
+		touchKeys  map[int]color.RGBA
+		screen     []byte
+		screenSent bool
+	}
+)
+
+//go:embed index.html
+var indexPage []byte
+
+// New creates a Server for the given client using the FakeDeck as
+// transport
+func New(client *streamdeck.Client, deck *streamdeck.FakeDeck) *Server {
+	s := &Server{client: client, deck: deck, mux: http.NewServeMux()}
+
+	s.mux.HandleFunc("GET /{$}", s.handleIndex)
+	s.mux.HandleFunc("GET /ws", s.handleWebsocket)
+
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) { s.mux.ServeHTTP(w, r) }
+
+func (*Server) handleIndex(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := w.Write(indexPage); err != nil {
+		logrus.WithError(err).Debug("writing simulator page")
+	}
+}
+
+func (s *Server) handleWebsocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logrus.WithError(err).Error("upgrading simulator connection")
+		return
+	}
+	defer conn.Close() //nolint:errcheck // connection is gone anyway
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	go func() {
+		defer cancel()
+
+		if err := s.readInput(conn); err != nil && !websocket.IsCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+			logrus.WithError(err).Debug("reading simulator input")
+		}
+	}()
+
+	if err := s.sendUpdates(ctx, conn); err != nil && !errors.Is(err, context.Canceled) {
+		logrus.WithError(err).Debug("sending simulator updates")
+	}
+}
+
+// readInput forwards the key presses of the page to the deck until the
+// connection is closed. Keys still held by the page are released.
+func (s *Server) readInput(conn *websocket.Conn) error {
+	held := make(map[int]bool)
+	defer func() {
+		for key := range held {
+			if err := s.deck.Release(key); err != nil {
+				logrus.WithError(err).WithField("key", key).Error("releasing simulated key")
+			}
+		}
+	}()
+
+	for {
+		var msg message
+		if err := conn.ReadJSON(&msg); err != nil {
+			return fmt.Errorf("reading message: %w", err)
+		}
+
+		var err error
+		switch msg.Type {
+		case msgTypeDown:
+			held[msg.Key] = true
+			err = s.deck.Press(msg.Key)
+
+		case msgTypeUp:
+			delete(held, msg.Key)
+			err = s.deck.Release(msg.Key)
+
+		default:
+			err = fmt.Errorf("unknown message type %q", msg.Type)
+		}
+
+		if err != nil {
+			logrus.WithError(err).WithField("key", msg.Key).Error("handling simulator input")
+		}
+	}
+}
+
+// sendUpdates sends the layout of the deck and afterwards every change
+// of the key images until the context is cancelled
+func (s *Server) sendUpdates(ctx context.Context, conn *websocket.Conn) error {
+	screen := s.client.LCDSize()
+	if s.client.HasInfoBar() {
+		screen = s.client.InfoBarSize()
+	}
+
+	if err := s.send(conn, message{
+		Type:      msgTypeLayout,
+		Columns:   s.client.KeyColumns(),
+		IconSize:  s.client.IconSize(),
+		Keys:      s.client.NumKeys(),
+		Model:     streamdeck.DeckToName[s.client.Model()],
+		Screen:    screen,
+		TouchKeys: s.client.NumTouchKeys(),
+	}); err != nil {
+		return err
+	}
+
+	state := pageState{
+		keys:      make(map[int]image.Image),
+		touchKeys: make(map[int]color.RGBA),
+	}
+
+	ticker := time.NewTicker(updateInterval)
+	defer ticker.Stop()
+
+	for {
+		msgs, err := s.changes(&state)
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range msgs {
+			if err = s.send(conn, msg); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// changes collects the messages for everything changed since the
+// state was last updated
+func (s *Server) changes(state *pageState) (msgs []message, err error) {
+	for key, img := range s.deck.KeyImages() {
+		if state.keys[key] == img {
+			continue
+		}
+
+		msg := message{Type: msgTypeKey, Key: key}
+		if msg.Image, err = dataURL(img); err != nil {
+			return nil, err
+		}
+
+		state.keys[key] = img
+		msgs = append(msgs, msg)
+	}
+
+	for i := range s.client.NumTouchKeys() {
+		col := s.deck.TouchKeyColor(i)
+		if last, ok := state.touchKeys[i]; ok && last == col {
+			continue
+		}
+
+		state.touchKeys[i] = col
+		msgs = append(msgs, message{
+			Type:  msgTypeKey,
+			Key:   s.client.NumKeys() + i,
+			Color: fmt.Sprintf("#%02x%02x%02x", col.R, col.G, col.B),
+		})
+	}
+
+	screen := s.deck.LCDImage()
+	if screen == nil {
+		screen = s.deck.InfoBarImage()
+	}
+
+	if screen == nil {
+		return msgs, nil
+	}
+
+	pix := screenPixels(screen)
+	if state.screenSent && bytes.Equal(pix, state.screen) {
+		return msgs, nil
+	}
+
+	msg := message{Type: msgTypeScreen}
+	if msg.Image, err = dataURL(screen); err != nil {
+		return nil, err
+	}
+
+	state.screen, state.screenSent = pix, true
+	return append(msgs, msg), nil
+}
+
+func (*Server) send(conn *websocket.Conn, msg message) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return fmt.Errorf("setting write deadline: %w", err)
+	}
+
+	if err := conn.WriteJSON(msg); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+
+	return nil
+}
+
+func dataURL(img image.Image) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		return "", fmt.Errorf("encoding image: %w", err)
+	}
+
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// screenPixels returns the pixels of the LCD or info bar copy returned
+// by the FakeDeck to detect changes
+func screenPixels(img image.Image) []byte {
+	if nrgba, ok := img.(*image.NRGBA); ok {
+		return nrgba.Pix
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package simulator
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Luzifer/streamdeck/v2"
+)
+
+func newTestServer(t *testing.T) (*streamdeck.Client, *websocket.Conn) {
+	t.Helper()
+
+	fake, err := streamdeck.NewFakeDeck(streamdeck.StreamDeckMini)
+	require.NoError(t, err)
+
+	client, err := streamdeck.NewWithTransport(streamdeck.StreamDeckMini, fake)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	srv := httptest.NewServer(New(client, fake))
+	t.Cleanup(srv.Close)
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return client, conn
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn, msgType string) message {
+	t.Helper()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+
+	for {
+		var msg message
+		require.NoError(t, conn.ReadJSON(&msg))
+
+		if msg.Type == msgType {
+			return msg
+		}
+	}
+}
+
+func TestIndexPage(t *testing.T) {
+	t.Parallel()
+
+	fake, err := streamdeck.NewFakeDeck(streamdeck.StreamDeckMini)
+	require.NoError(t, err)
+
+	client, err := streamdeck.NewWithTransport(streamdeck.StreamDeckMini, fake)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	rec := httptest.NewRecorder()
+	New(client, fake).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<title>StreamDeck Simulator</title>")
+}
+
+func TestLayoutAndKeyImages(t *testing.T) {
+	t.Parallel()
+
+	client, conn := newTestServer(t)
+
+	layout := readMessage(t, conn, msgTypeLayout)
+	assert.Equal(t, message{
+		Type:     msgTypeLayout,
+		Columns:  3,
+		IconSize: client.IconSize(),
+		Keys:     6,
+		Model:    "StreamDeck Mini",
+	}, layout)
+
+	require.NoError(t, client.FillColor(4, color.RGBA{0xff, 0x0, 0x0, 0xff}))
+
+	key := readMessage(t, conn, msgTypeKey)
+	assert.Equal(t, 4, key.Key)
+	assert.True(t, strings.HasPrefix(key.Image, "data:image/png;base64,"))
+}
+
+func TestKeyInput(t *testing.T) {
+	t.Parallel()
+
+	client, conn := newTestServer(t)
+	readMessage(t, conn, msgTypeLayout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	events := client.Subscribe(ctx)
+
+	require.NoError(t, conn.WriteJSON(message{Type: msgTypeDown, Key: 2}))
+	require.NoError(t, conn.WriteJSON(message{Type: msgTypeUp, Key: 2}))
+
+	var got []streamdeck.EventType
+	for evt := range events {
+		if evt.Key != 2 || (evt.Type != streamdeck.EventTypeDown && evt.Type != streamdeck.EventTypeUp) {
+			continue
+		}
+
+		if got = append(got, evt.Type); evt.Type == streamdeck.EventTypeUp {
+			break
+		}
+	}
+
+	assert.Equal(t, []streamdeck.EventType{streamdeck.EventTypeDown, streamdeck.EventTypeUp}, got)
+}
+
+func TestScreenPixels(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	assert.Len(t, screenPixels(img), 16)
+	assert.Nil(t, screenPixels(image.NewRGBA(image.Rect(0, 0, 2, 2))))
+}
@@ -0,0 +1,92 @@
+// Package snapshot renders key displays off-screen and composes them
+// into a single image of the deck.
+package snapshot
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/helpers"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules/opts"
+)
+
+// BezelColor is drawn into the gaps between the keys of a composite
+var BezelColor = color.RGBA{0x1a, 0x1a, 0x1a, 0xff}
+
+// Deck implements opts.Deck by keeping the images drawn on its keys
+// in memory instead of sending them to a device
+type Deck struct {
+	iconSize int
+
+	lock   sync.Mutex
+	images map[int]image.Image
+}
+
+var _ opts.Deck = (*Deck)(nil)
+
+// NewDeck creates an off-screen deck with keys of the given size
+func NewDeck(iconSize int) *Deck {
+	return &Deck{iconSize: iconSize, images: make(map[int]image.Image)}
+}
+
+// Composite draws all keys in a grid of the given number of columns
+// and rows with gap pixels of BezelColor between them. Keys never
+// drawn on are black.
+func (d *Deck) Composite(columns, rows, gap int) *image.RGBA {
+	var (
+		step = d.iconSize + gap
+		out  = image.NewRGBA(image.Rect(0, 0, columns*step-gap, rows*step-gap))
+	)
+
+	draw.Draw(out, out.Bounds(), image.NewUniform(BezelColor), image.Point{}, draw.Src)
+
+	for idx := range columns * rows {
+		rect := image.Rect(0, 0, d.iconSize, d.iconSize).Add(image.Pt(idx%columns*step, idx/columns*step))
+
+		img := d.Image(idx)
+		if img == nil {
+			draw.Draw(out, rect, image.NewUniform(color.Black), image.Point{}, draw.Src)
+			continue
+		}
+
+		draw.Draw(out, rect, img, img.Bounds().Min, draw.Src)
+	}
+
+	return out
+}
+
+// FillColor fills the key with a solid color
+func (d *Deck) FillColor(keyIdx int, col color.RGBA) error {
+	img := image.NewRGBA(image.Rect(0, 0, d.iconSize, d.iconSize))
+	draw.Draw(img, img.Bounds(), image.NewUniform(col), image.Point{}, draw.Src)
+
+	return d.FillImage(keyIdx, img)
+}
+
+// FillImage stores the image for the key, images not matching the
+// icon size are scaled like the renderers do
+func (d *Deck) FillImage(keyIdx int, img image.Image) error {
+	img = helpers.AutoSizeImage(img, d.iconSize)
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.images[keyIdx] = img
+	return nil
+}
+
+// IconSize returns the size of the keys
+func (d *Deck) IconSize() int { return d.iconSize }
+
+// Image returns the image drawn on the key or nil if nothing was drawn
+func (d *Deck) Image(keyIdx int) image.Image {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return d.images[keyIdx]
+}
+
+// SetBrightness is ignored as there is no device
+func (*Deck) SetBrightness(int) error { return nil }
@@ -0,0 +1,42 @@
+package snapshot
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposite(t *testing.T) {
+	t.Parallel()
+
+	var (
+		deck = NewDeck(10)
+		red  = color.RGBA{0xff, 0x0, 0x0, 0xff}
+	)
+
+	require.NoError(t, deck.FillColor(4, red))
+
+	img := deck.Composite(3, 2, 2)
+	assert.Equal(t, image.Rect(0, 0, 34, 22), img.Bounds())
+
+	// Key 4 is second column of second row
+	assert.Equal(t, red, img.RGBAAt(12, 12))
+	assert.Equal(t, red, img.RGBAAt(21, 21))
+
+	// Gaps show the bezel, other keys are black
+	assert.Equal(t, BezelColor, img.RGBAAt(10, 12))
+	assert.Equal(t, color.RGBA{0x0, 0x0, 0x0, 0xff}, img.RGBAAt(0, 0))
+}
+
+func TestFillImageScales(t *testing.T) {
+	t.Parallel()
+
+	deck := NewDeck(10)
+	require.NoError(t, deck.FillImage(0, image.NewRGBA(image.Rect(0, 0, 40, 40))))
+
+	assert.Equal(t, image.Pt(10, 10), deck.Image(0).Bounds().Size())
+	assert.Nil(t, deck.Image(1))
+}
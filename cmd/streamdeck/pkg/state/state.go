@@ -0,0 +1,222 @@
+// Package state contains the variable store shared by all decks and
+// modules.
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// defaultToggleValues are cycled through when toggling without values
+var defaultToggleValues = []string{"true", "false"}
+
+// Store holds string values by their name, it is safe for concurrent
+// use. Changes are announced to watchers of the value and written to
+// the file of the store if it was opened from a file.
+type Store struct {
+	file     string
+	lock     sync.RWMutex
+	values   map[string]string
+	watchers map[string][]chan struct{}
+}
+
+// New creates an empty Store kept in memory
+func New() *Store {
+	return &Store{
+		values:   make(map[string]string),
+		watchers: make(map[string][]chan struct{}),
+	}
+}
+
+// Open creates a Store persisted into the file, values stored before
+// are loaded if the file exists
+func Open(file string) (*Store, error) {
+	s := New()
+	s.file = file
+
+	content, err := os.ReadFile(file) //#nosec:G304 // intended to read user-defined file
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return s, nil
+
+	case err != nil:
+		return nil, fmt.Errorf("reading state file: %w", err)
+	}
+
+	if err = json.Unmarshal(content, &s.values); err != nil {
+		return nil, fmt.Errorf("decoding state file: %w", err)
+	}
+
+	if s.values == nil {
+		// File contained null
+		s.values = make(map[string]string)
+	}
+
+	return s, nil
+}
+
+// All returns a copy of all values
+func (s *Store) All() map[string]string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return maps.Clone(s.values)
+}
+
+// Get returns the value and whether it is set
+func (s *Store) Get(name string) (string, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	v, ok := s.values[name]
+	return v, ok
+}
+
+// Increment adds the step to the integer value (unset values count as
+// zero) and returns the new value
+func (s *Store) Increment(name string, step int64) (string, error) {
+	return s.update(name, func(current string, ok bool) (string, error) {
+		if !ok || current == "" {
+			current = "0"
+		}
+
+		n, err := strconv.ParseInt(current, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("value %q of %q is no integer", current, name)
+		}
+
+		return strconv.FormatInt(n+step, 10), nil
+	})
+}
+
+// Set sets the value
+func (s *Store) Set(name, value string) error {
+	_, err := s.update(name, func(string, bool) (string, error) { return value, nil })
+	return err
+}
+
+// Toggle sets the value following the current one in the values and
+// returns it. Unset values and values not contained are set to the
+// first value. Without values the value is toggled between "true" and
+// "false".
+func (s *Store) Toggle(name string, values ...string) (string, error) {
+	if len(values) == 0 {
+		values = defaultToggleValues
+	}
+
+	return s.update(name, func(current string, ok bool) (string, error) {
+		idx := -1
+		if ok {
+			idx = slices.Index(values, current)
+		}
+
+		return values[(idx+1)%len(values)], nil
+	})
+}
+
+// Watch returns a channel receiving a signal whenever the value is
+// changed until the context is cancelled. Changes happening while the
+// previous signal was not yet received are combined into one signal.
+func (s *Store) Watch(ctx context.Context, name string) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+
+	s.lock.Lock()
+	s.watchers[name] = append(s.watchers[name], ch)
+	s.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		s.watchers[name] = slices.DeleteFunc(s.watchers[name], func(c chan struct{}) bool { return c == ch })
+		if len(s.watchers[name]) == 0 {
+			delete(s.watchers, name)
+		}
+	}()
+
+	return ch
+}
+
+// update changes the value, notifies its watchers and persists the
+// store when the value was changed
+func (s *Store) update(name string, fn func(current string, ok bool) (string, error)) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	current, ok := s.values[name]
+
+	value, err := fn(current, ok)
+	if err != nil {
+		return current, err
+	}
+
+	if ok && value == current {
+		return value, nil
+	}
+
+	s.values[name] = value
+
+	for _, ch := range s.watchers[name] {
+		select {
+		case ch <- struct{}{}:
+		default:
+			// Watcher is already notified
+		}
+	}
+
+	if err = s.persist(); err != nil {
+		return value, err
+	}
+
+	return value, nil
+}
+
+// persist writes all values to the file of the store, the lock must be
+// held while writing
+func (s *Store) persist() error {
+	if s.file == "" {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(s.values, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+
+	// Write next to the file and move it into place to never leave a
+	// partially written file behind
+	tmp, err := os.CreateTemp(filepath.Dir(s.file), "."+filepath.Base(s.file)+".*")
+	if err != nil {
+		return fmt.Errorf("creating state file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // file is moved on success
+
+	if _, err = tmp.Write(content); err != nil {
+		tmp.Close() //nolint:errcheck,gosec // write error is reported
+		return fmt.Errorf("writing state file: %w", err)
+	}
+
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("closing state file: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), s.file); err != nil {
+		return fmt.Errorf("replacing state file: %w", err)
+	}
+
+	return nil
+}
+
+// BrightnessKey returns the name of the value holding the brightness
+// of the deck with the serial
+func BrightnessKey(serial string) string { return "brightness." + serial }
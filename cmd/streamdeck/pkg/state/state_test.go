@@ -0,0 +1,105 @@
+package state
+
+import (
+	"context"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToggle(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+
+	for _, expect := range []string{"true", "false", "true"} {
+		v, err := s.Toggle("mic")
+		require.NoError(t, err)
+		assert.Equal(t, expect, v)
+	}
+
+	require.NoError(t, s.Set("mode", "unknown"))
+	for _, expect := range []string{"a", "b", "c", "a"} {
+		v, err := s.Toggle("mode", "a", "b", "c")
+		require.NoError(t, err)
+		assert.Equal(t, expect, v)
+	}
+}
+
+func TestIncrement(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+
+	v, err := s.Increment("count", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "1", v)
+
+	v, err = s.Increment("count", -3)
+	require.NoError(t, err)
+	assert.Equal(t, "-2", v)
+
+	require.NoError(t, s.Set("name", "foo"))
+	_, err = s.Increment("name", 1)
+	assert.Error(t, err)
+
+	v, _ = s.Get("name")
+	assert.Equal(t, "foo", v)
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	s := New()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := s.Watch(ctx, "mic")
+
+	require.NoError(t, s.Set("other", "x"))
+	require.NoError(t, s.Set("mic", "on"))
+	require.NoError(t, s.Set("mic", "off"))
+
+	// Changes are combined into one signal
+	<-changes
+	select {
+	case <-changes:
+		t.Fatal("received second signal")
+	default:
+	}
+
+	// Setting the same value is no change
+	require.NoError(t, s.Set("mic", "off"))
+	select {
+	case <-changes:
+		t.Fatal("received signal without change")
+	default:
+	}
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		s.lock.RLock()
+		defer s.lock.RUnlock()
+		return len(s.watchers) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestOpenPersists(t *testing.T) {
+	t.Parallel()
+
+	file := path.Join(t.TempDir(), "state.json")
+
+	s, err := Open(file)
+	require.NoError(t, err)
+	assert.Empty(t, s.All())
+
+	require.NoError(t, s.Set("mic", "muted"))
+	_, err = s.Increment("count", 2) //revive:disable-line:add-constant // test value
+	require.NoError(t, err)
+
+	s, err = Open(file)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"count": "2", "mic": "muted"}, s.All())
+}
@@ -0,0 +1,140 @@
+// Package templating evaluates Go templates within the attributes of
+// actions and display elements when they are executed or rendered.
+package templating
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"go.yaml.in/yaml/v3"
+)
+
+// Data contains the values available to templates
+type Data struct {
+	// Key is the index of the key or dial the element belongs to, -1
+	// for elements not bound to a single key (chords, touch strip)
+	Key    int
+	Model  string
+	Page   string
+	Serial string
+	// State contains the values of the shared variable store
+	State map[string]string
+	Time  time.Time
+}
+
+var (
+	funcs = template.FuncMap{
+		"date": func(layout string, t time.Time) string { return t.Format(layout) },
+		"default": func(def string, v any) any {
+			if v == nil || v == "" {
+				return def
+			}
+			return v
+		},
+		"now": time.Now,
+	}
+
+	parsed sync.Map
+)
+
+// Expand returns a copy of the attributes with all templates in strings
+// evaluated against the data. Attributes without templates are returned
+// unchanged.
+func Expand(atts config.DynamicAttributes, data Data) (config.DynamicAttributes, error) {
+	if !HasTemplate(atts) {
+		return atts, nil
+	}
+
+	out, err := expandNode(&atts, data)
+	if err != nil {
+		return atts, err
+	}
+
+	return *out, nil
+}
+
+func expandNode(node *yaml.Node, data Data) (*yaml.Node, error) {
+	c := *node
+	c.Content = make([]*yaml.Node, len(node.Content))
+
+	for i, child := range node.Content {
+		expanded, err := expandNode(child, data)
+		if err != nil {
+			return nil, err
+		}
+		c.Content[i] = expanded
+	}
+
+	if !isTemplate(node) {
+		return &c, nil
+	}
+
+	tpl, err := parse(node.Value)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: parsing template: %w", node.Line, err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err = tpl.Execute(buf, data); err != nil {
+		return nil, fmt.Errorf("line %d: executing template: %w", node.Line, err)
+	}
+
+	c.Value = buf.String()
+	return &c, nil
+}
+
+// Check reports whether the template within the value parses
+func Check(value string) error {
+	if _, err := parse(value); err != nil {
+		return fmt.Errorf("parsing template: %w", err)
+	}
+
+	return nil
+}
+
+// HasTemplate reports whether any string within the attributes contains
+// a template, the attributes then differ between renders
+func HasTemplate(atts config.DynamicAttributes) bool { return hasTemplate(&atts) }
+
+// IsTemplate reports whether the value contains a template and is
+// only known when executing or rendering the element
+func IsTemplate(value string) bool { return strings.Contains(value, "{{") }
+
+func hasTemplate(node *yaml.Node) bool {
+	if isTemplate(node) {
+		return true
+	}
+
+	for _, child := range node.Content {
+		if hasTemplate(child) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isTemplate(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!str" && IsTemplate(node.Value)
+}
+
+// parse returns the parsed template for the text, templates are parsed
+// once as they are evaluated on every render
+func parse(text string) (*template.Template, error) {
+	if tpl, ok := parsed.Load(text); ok {
+		return tpl.(*template.Template), nil //nolint:forcetypeassert // only templates are stored
+	}
+
+	tpl, err := template.New("attribute").Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // wrapped by caller
+	}
+
+	parsed.Store(text, tpl)
+	return tpl, nil
+}
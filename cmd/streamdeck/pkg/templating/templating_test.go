@@ -0,0 +1,69 @@
+package templating
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	atts, err := config.EncodeAttributes(map[string]any{
+		"caption": "{{ .Time | date \"15:04\" }}",
+		"command": []string{"notify", "{{ .Page }}/{{ .Key }} on {{ .Model }} ({{ .Serial }})"},
+		"size":    12,
+		"text":    "{{ .State.mic | default \"unknown\" }} / {{ .State.cam | default \"off\" }}",
+	})
+	require.NoError(t, err)
+
+	out, err := Expand(atts, Data{
+		Key:    3,
+		Model:  "StreamDeck Mini",
+		Page:   "main",
+		Serial: "AL01",
+		State:  map[string]string{"mic": "muted"},
+		Time:   time.Date(2024, 1, 2, 13, 37, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	expanded, err := config.DecodeAttributes[map[string]any](out)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"caption": "13:37",
+		"command": []any{"notify", "main/3 on StreamDeck Mini (AL01)"},
+		"size":    12,
+		"text":    "muted / off",
+	}, expanded)
+
+	// The attributes of the configuration are kept
+	original, err := config.DecodeAttributes[map[string]any](atts)
+	require.NoError(t, err)
+	assert.Equal(t, "{{ .Page }}/{{ .Key }} on {{ .Model }} ({{ .Serial }})", original["command"].([]any)[1]) //nolint:forcetypeassert // fails the test anyway
+}
+
+func TestExpandErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, text := range []string{"{{ .Page", "{{ .Unknown }}", "{{ nope }}"} {
+		atts, err := config.EncodeAttributes(map[string]string{"text": text})
+		require.NoError(t, err)
+
+		_, err = Expand(atts, Data{})
+		assert.Error(t, err, text)
+	}
+}
+
+func TestExpandWithoutTemplates(t *testing.T) {
+	t.Parallel()
+
+	atts, err := config.EncodeAttributes(map[string]string{"text": "{ plain }"})
+	require.NoError(t, err)
+
+	out, err := Expand(atts, Data{})
+	require.NoError(t, err)
+	assert.Equal(t, atts, out)
+}
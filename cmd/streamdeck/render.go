@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/snapshot"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Luzifer/streamdeck/v2"
+)
+
+// renderCommand renders the keys of a page for the given model into
+// a PNG file without using a device
+func renderCommand() (err error) {
+	if cfg.Model == "" {
+		return fmt.Errorf("no model given")
+	}
+
+	model, err := parseModel(cfg.Model)
+	if err != nil {
+		return fmt.Errorf("parsing model: %w", err)
+	}
+
+	if userConfig, err = config.Load(cfg.Config); err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	// Virtual deck provides the layout and panel helpers
+	_, client, serial, err := openVirtualDeck(model)
+	if err != nil {
+		return err
+	}
+	defer client.Close() //nolint:errcheck // virtual deck, nothing to clean up
+
+	if !client.HasDisplay() {
+		return fmt.Errorf("%s has no display", streamdeck.DeckToName[model])
+	}
+
+	d := &deckState{
+		client: client,
+		conf:   userConfig.ForDeck(serial, client),
+		serial: serial,
+	}
+
+	page := cfg.Page
+	if page == "" {
+		page = d.conf.DefaultPage
+	}
+
+	img, err := d.renderSnapshot(context.Background(), page)
+	if err != nil {
+		return fmt.Errorf("rendering page %q: %w", page, err)
+	}
+
+	f, err := os.Create(cfg.Out)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // closed explicitly below
+
+	if err = png.Encode(f, img); err != nil {
+		return fmt.Errorf("encoding image: %w", err)
+	}
+
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("closing output file: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{"file": cfg.Out, "page": page}).Info("Page rendered")
+	return nil
+}
+
+// renderSnapshot renders all keys of the page once into an off-screen
+// deck and returns the composite of all keys. Animations show their
+// first frame, displays failing to render show the error display.
+func (d *deckState) renderSnapshot(ctx context.Context, name string) (*image.RGBA, error) {
+	page, ok := d.conf.Pages[name]
+	if !ok {
+		return nil, fmt.Errorf("page not found")
+	}
+
+	deck := snapshot.NewDeck(d.client.IconSize())
+
+	var panel *panelState
+	if def := page.GetPanel(d.conf); def.IsDefined() {
+		var err error
+		if panel, err = d.loadPanel(ctx, def); err != nil {
+			return nil, fmt.Errorf("loading panel wallpaper: %w", err)
+		}
+	}
+
+	rt := d.moduleRuntime(name)
+	rt.Deck = deck
+
+	keys := page.GetKeyDefinitions(d.conf)
+	for idx := range d.client.NumKeys() {
+		rt.Background = func() image.Image { return panel.tile(idx) }
+		rt.Key = idx
+
+		kd, ok := keys[idx]
+		kd = d.keyForState(name, idx, kd)
+
+		if ok && kd.Display.Type != "" {
+			if err := modules.CallDisplayElementOnce(ctx, idx, rt, kd); err != nil {
+				d.logger().WithError(err).WithFields(logrus.Fields{"key": idx, "page": name}).Error("Unable to execute display element")
+
+				if err = modules.CallErrorDisplayElement(ctx, idx, rt); err != nil {
+					return nil, fmt.Errorf("rendering error display on key %d: %w", idx, err)
+				}
+			}
+			continue
+		}
+
+		if tile := panel.tile(idx); tile != nil {
+			if err := deck.FillImage(idx, tile); err != nil {
+				return nil, fmt.Errorf("drawing wallpaper on key %d: %w", idx, err)
+			}
+		}
+	}
+
+	return deck.Composite(d.client.KeyColumns(), d.client.KeyRows(), cfg.Gap), nil
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules"
+)
+
+// schemaCommand prints the JSON Schema of the configuration file to be
+// used by editors for validation and completion
+func schemaCommand() error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(modules.ConfigSchema()); err != nil {
+		return fmt.Errorf("encoding schema: %w", err)
+	}
+
+	return nil
+}
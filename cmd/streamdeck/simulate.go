@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/simulator"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Luzifer/streamdeck/v2"
+)
+
+const simulatorReadHeaderTimeout = 5 * time.Second
+
+// openSimulatedDeck creates a virtual deck of the model given on the
+// command line and serves the simulator page to show and control it
+func openSimulatedDeck() error {
+	model, err := parseModel(cfg.Simulate)
+	if err != nil {
+		return fmt.Errorf("parsing simulated model: %w", err)
+	}
+
+	fake, client, serial, err := openVirtualDeck(model)
+	if err != nil {
+		return err
+	}
+
+	decks[serial] = newDeckState(client, serial)
+
+	srv := &http.Server{
+		Addr:              cfg.SimulateListen,
+		Handler:           simulator.New(client, fake),
+		ReadHeaderTimeout: simulatorReadHeaderTimeout,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.WithError(err).Fatal("serving simulator")
+		}
+	}()
+
+	logrus.WithFields(logrus.Fields{
+		"model":  streamdeck.DeckToName[model],
+		"serial": serial,
+		"url":    "http://" + cfg.SimulateListen + "/",
+	}).Info("Simulating StreamDeck")
+
+	return nil
+}
+
+// openVirtualDeck opens a client for an in-memory deck of the model.
+// The deck uses the first serial given on the command line to select
+// the configuration of that deck.
+func openVirtualDeck(model uint16) (fake *streamdeck.FakeDeck, client *streamdeck.Client, serial string, err error) {
+	if fake, err = streamdeck.NewFakeDeck(model); err != nil {
+		return nil, nil, "", fmt.Errorf("creating virtual deck: %w", err)
+	}
+
+	if len(cfg.Serial) > 0 {
+		fake.SetSerial(cfg.Serial[0])
+	}
+
+	if client, err = streamdeck.NewWithTransport(model, fake); err != nil {
+		return nil, nil, "", fmt.Errorf("opening virtual deck: %w", err)
+	}
+
+	if serial, err = client.Serial(); err != nil {
+		return nil, nil, "", fmt.Errorf("reading serial of virtual deck: %w", err)
+	}
+
+	return fake, client, serial, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/config"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Luzifer/streamdeck/v2"
+)
+
+// validateCommand checks the configuration and prints all problems
+// found with their position in the file
+func validateCommand() error {
+	var deck *streamdeck.Client
+	if cfg.Model != "" {
+		model, err := parseModel(cfg.Model)
+		if err != nil {
+			return fmt.Errorf("parsing model: %w", err)
+		}
+
+		if _, deck, _, err = openVirtualDeck(model); err != nil {
+			return err
+		}
+		defer deck.Close() //nolint:errcheck // virtual deck, nothing to clean up
+	}
+
+	problems, err := config.Validate(cfg.Config, deck, modules.ValidateElement)
+	if err != nil {
+		return fmt.Errorf("validating config: %w", err)
+	}
+
+	for _, p := range problems {
+		if p.File == "" {
+			p.File = cfg.Config
+		}
+
+		fmt.Println(p.String()) //nolint:forbidigo // printing explicitly requested
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problems in config", len(problems))
+	}
+
+	logrus.WithField("config", cfg.Config).Info("Config is valid")
+	return nil
+}
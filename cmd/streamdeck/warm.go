@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules"
+	"github.com/Luzifer/streamdeck/cmd/streamdeck/v2/pkg/modules/opts"
+	"github.com/sirupsen/logrus"
+
+	"github.com/Luzifer/streamdeck/v2"
+)
+
+// cacheWarmDeck implements opts.Deck by encoding the rendered images
+// into the encode cache of the library instead of writing them to the
+// device
+type cacheWarmDeck struct {
+	client *streamdeck.Client
+}
+
+var _ opts.Deck = cacheWarmDeck{}
+
+func (c cacheWarmDeck) FillColor(_ int, col color.RGBA) error {
+	// Same image the client draws for colored keys
+	img := image.NewRGBA(image.Rect(0, 0, c.IconSize(), c.IconSize()))
+	draw.Draw(img, img.Bounds(), image.NewUniform(col), image.Point{}, draw.Src)
+
+	_, err := c.client.Encode(img)
+	return err //nolint:wrapcheck // wraps internal interface
+}
+
+func (c cacheWarmDeck) FillImage(_ int, img image.Image) error {
+	_, err := c.client.Encode(img)
+	return err //nolint:wrapcheck // wraps internal interface
+}
+
+func (c cacheWarmDeck) IconSize() int { return c.client.IconSize() }
+
+func (cacheWarmDeck) SetBrightness(int) error { return nil }
+
+// warmEncodeCache renders the static keys and wallpapers of all pages
+// into the encode cache so switching to a page for the first time does
+// not need to encode its images. Keys with displays executing commands,
+// fetching content or refreshing are not rendered. The runtime must be
+// created before as the configuration of the deck might be reloaded
+// while warming.
+func (d *deckState) warmEncodeCache(ctx context.Context, rt opts.Runtime) {
+	client := d.currentClient()
+	if !client.HasDisplay() {
+		return
+	}
+
+	deck := cacheWarmDeck{client}
+	rt.Deck = deck
+
+	// Keys without display are cleared
+	if err := deck.FillColor(0, color.RGBA{0x0, 0x0, 0x0, 0xff}); err != nil {
+		d.logger().WithError(err).Debug("Unable to warm encode cache")
+		return
+	}
+
+	for name, page := range rt.Conf.Pages {
+		if ctx.Err() != nil {
+			return
+		}
+
+		var panel *panelState
+		if def := page.GetPanel(rt.Conf); def.IsDefined() {
+			var err error
+			if panel, err = d.loadPanel(ctx, def); err != nil {
+				d.logger().WithError(err).WithField("page", name).Debug("Unable to load panel wallpaper for encode cache")
+			}
+		}
+
+		rt.Page = name
+
+		keys := page.GetKeyDefinitions(rt.Conf)
+		for idx := range client.NumKeys() {
+			rt.Background = func() image.Image { return panel.tile(idx) }
+			rt.Key = idx
+
+			kd, ok := keys[idx]
+			kd = d.keyForState(name, idx, kd)
+
+			switch {
+			case ok && kd.Display.Type != "":
+				if !modules.IsStaticDisplay(kd) {
+					continue
+				}
+
+				if err := modules.CallDisplayElement(ctx, idx, rt, kd); err != nil {
+					d.logger().WithError(err).WithFields(logrus.Fields{"key": idx, "page": name}).Debug("Unable to warm encode cache")
+				}
+
+			case panel != nil && !panel.animated():
+				if tile := panel.tile(idx); tile != nil {
+					if err := deck.FillImage(idx, tile); err != nil {
+						d.logger().WithError(err).WithFields(logrus.Fields{"key": idx, "page": name}).Debug("Unable to warm encode cache")
+					}
+				}
+			}
+		}
+	}
+
+	stats := client.EncodeCacheStats()
+	d.logger().WithFields(logrus.Fields{
+		"entries": stats.Entries,
+		"hits":    stats.Hits,
+		"misses":  stats.Misses,
+	}).Debug("Encode cache warmed")
+}
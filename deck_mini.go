@@ -10,7 +10,6 @@ import (
 	"sync"
 
 	"github.com/disintegration/imaging"
-	"github.com/sstallion/go-hid"
 	"golang.org/x/image/bmp"
 )
 
@@ -20,7 +19,7 @@ const (
 )
 
 type deckConfigMini struct {
-	dev       *hid.Device
+	dev       Transport
 	writeLock sync.Mutex
 
 	keyState []EventType
@@ -59,48 +58,23 @@ func (d *deckConfigMini) FillColor(keyIdx int, col color.RGBA) error {
 	return d.FillImage(keyIdx, img)
 }
 
-func (d *deckConfigMini) FillImage(keyIdx int, img image.Image) error {
-	if keyIdx >= d.NumKeys() || keyIdx < 0 {
-		return fmt.Errorf("key index %d out of bounds", keyIdx)
-	}
-
-	d.writeLock.Lock()
-	defer d.writeLock.Unlock()
-
+func (*deckConfigMini) EncodeImage(img image.Image) ([]byte, error) {
 	buf := new(bytes.Buffer)
 	rimg := imaging.Transpose(img)
 	if err := bmp.Encode(buf, rimg); err != nil {
-		return fmt.Errorf("encoding bmp: %w", err)
+		return nil, fmt.Errorf("encoding bmp: %w", err)
 	}
 
-	var partIndex int16
-	for buf.Len() > 0 {
-		chunk := make([]byte, deckMiniMaxPacketSize-deckMiniHeaderSize)
-		n, err := buf.Read(chunk)
-		if err != nil {
-			return fmt.Errorf("reading image chunk: %w", err)
-		}
-
-		var last uint8
-		if n < deckMiniMaxPacketSize-deckMiniHeaderSize || buf.Len() == 0 {
-			last = 1
-		}
-
-		header := make([]byte, deckMiniHeaderSize)
-		header[0] = 0x02
-		header[1] = 0x01
-		header[2] = byte(partIndex)
-		header[4] = last
-		header[5] = byte(keyIdx + 1) //#nosec:G115 // keyIdx is guarded to safe values
-
-		if _, err = d.dev.Write(append(header, chunk...)); err != nil {
-			return fmt.Errorf("sending image chunk: %w", err)
-		}
+	return buf.Bytes(), nil
+}
 
-		partIndex++
+func (d *deckConfigMini) FillImage(keyIdx int, img image.Image) error {
+	payload, err := d.EncodeImage(img)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return d.WriteEncoded(keyIdx, payload)
 }
 
 func (d *deckConfigMini) FillPanel(img image.RGBA) error {
@@ -181,6 +155,46 @@ func (d *deckConfigMini) SetBrightness(pct int) error {
 	return nil
 }
 
-func (d *deckConfigMini) SetDevice(dev *hid.Device) { d.dev = dev }
+func (d *deckConfigMini) SetDevice(dev Transport) { d.dev = dev }
 
 func (*deckConfigMini) TransformKeyIndex(keyIdx int) int { return keyIdx }
+
+func (d *deckConfigMini) WriteEncoded(keyIdx int, payload []byte) error {
+	if keyIdx >= d.NumKeys() || keyIdx < 0 {
+		return fmt.Errorf("key index %d out of bounds", keyIdx)
+	}
+
+	d.writeLock.Lock()
+	defer d.writeLock.Unlock()
+
+	buf := bytes.NewBuffer(payload)
+
+	var partIndex int16
+	for buf.Len() > 0 {
+		chunk := make([]byte, deckMiniMaxPacketSize-deckMiniHeaderSize)
+		n, err := buf.Read(chunk)
+		if err != nil {
+			return fmt.Errorf("reading image chunk: %w", err)
+		}
+
+		var last uint8
+		if n < deckMiniMaxPacketSize-deckMiniHeaderSize || buf.Len() == 0 {
+			last = 1
+		}
+
+		header := make([]byte, deckMiniHeaderSize)
+		header[0] = 0x02
+		header[1] = 0x01
+		header[2] = byte(partIndex)
+		header[4] = last
+		header[5] = byte(keyIdx + 1) //#nosec:G115 // keyIdx is guarded to safe values
+
+		if _, err = d.dev.Write(append(header, chunk...)); err != nil {
+			return fmt.Errorf("sending image chunk: %w", err)
+		}
+
+		partIndex++
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package streamdeck
+
+//revive:disable:add-constant // many numbers with single use or only protocol value
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"strings"
+	"sync"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	deckNeoMaxPacketSize = 1024
+	deckNeoHeaderSize    = 8
+	deckNeoInfoBarWidth  = 248
+	deckNeoInfoBarHeight = 58
+)
+
+type deckConfigNeo struct {
+	dev       Transport
+	writeLock sync.Mutex
+
+	keyState []EventType
+}
+
+func newDeckConfigNeo() deckConfig {
+	d := &deckConfigNeo{}
+	d.keyState = make([]EventType, d.NumKeys())
+
+	return d
+}
+
+func (d *deckConfigNeo) ClearAllKeys() error {
+	for i := 0; i < d.NumKeys(); i++ {
+		if err := d.ClearKey(i); err != nil {
+			return fmt.Errorf("clearing key: %w", err)
+		}
+	}
+	return nil
+}
+
+func (d *deckConfigNeo) ClearKey(keyIdx int) error {
+	return d.FillColor(keyIdx, color.RGBA{0x0, 0x0, 0x0, 0xff})
+}
+
+func (d *deckConfigNeo) FillColor(keyIdx int, col color.RGBA) error {
+	img := image.NewRGBA(image.Rect(0, 0, d.IconSize(), d.IconSize()))
+
+	for x := 0; x < d.IconSize(); x++ {
+		for y := 0; y < d.IconSize(); y++ {
+			img.Set(x, y, col)
+		}
+	}
+
+	return d.FillImage(keyIdx, img)
+}
+
+func (*deckConfigNeo) EncodeImage(img image.Image) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	// We need to rotate the image or it will be presented upside down
+	rimg := imaging.Rotate180(img)
+
+	if err := jpeg.Encode(buf, rimg, &jpeg.Options{Quality: 95}); err != nil {
+		return nil, fmt.Errorf("encoding jpeg: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (d *deckConfigNeo) FillImage(keyIdx int, img image.Image) error {
+	payload, err := d.EncodeImage(img)
+	if err != nil {
+		return err
+	}
+
+	return d.WriteEncoded(keyIdx, payload)
+}
+
+func (d *deckConfigNeo) FillInfoBar(img image.Image) error {
+	if img.Bounds().Size() != d.InfoBarSize() {
+		img = imaging.PasteCenter(
+			imaging.New(deckNeoInfoBarWidth, deckNeoInfoBarHeight, color.RGBA{0x0, 0x0, 0x0, 0xff}),
+			imaging.Fit(img, deckNeoInfoBarWidth, deckNeoInfoBarHeight, imaging.Lanczos),
+		)
+	}
+
+	payload, err := d.EncodeImage(img)
+	if err != nil {
+		return err
+	}
+
+	return d.writePayload(0x0b, 0x00, payload)
+}
+
+func (d *deckConfigNeo) FillPanel(img image.RGBA) error {
+	if img.Bounds().Size().X < d.KeyColumns()*d.IconSize() || img.Bounds().Size().Y < d.KeyRows()*d.IconSize() {
+		return fmt.Errorf("image is too small")
+	}
+
+	for k := 0; k < d.NumKeys(); k++ {
+		var (
+			ky = k / d.KeyColumns()
+			kx = k % d.KeyColumns()
+		)
+
+		if err := d.FillImage(k, img.SubImage(image.Rect(kx*d.IconSize(), ky*d.IconSize(), (kx+1)*d.IconSize(), (ky+1)*d.IconSize()))); err != nil {
+			return fmt.Errorf("setting key image: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (d *deckConfigNeo) GetFimwareVersion() (string, error) {
+	fw := make([]byte, 32)
+	fw[0] = 5
+
+	_, err := d.dev.GetFeatureReport(fw)
+	if err != nil {
+		return "", fmt.Errorf("getting feature report: %w", err)
+	}
+
+	return strings.TrimRight(string(fw[6:]), "\x00"), nil
+}
+
+func (d *deckConfigNeo) IconBytes() int { return d.IconSize() * d.IconSize() * 3 }
+
+func (*deckConfigNeo) IconSize() int { return 96 }
+
+func (*deckConfigNeo) InfoBarSize() image.Point {
+	return image.Pt(deckNeoInfoBarWidth, deckNeoInfoBarHeight)
+}
+
+func (*deckConfigNeo) KeyColumns() int { return 4 }
+
+func (*deckConfigNeo) KeyDataOffset() int { return 4 }
+
+func (*deckConfigNeo) KeyDirection() keyDirection { return keyDirectionLTR }
+
+func (*deckConfigNeo) KeyRows() int { return 2 }
+
+func (*deckConfigNeo) Model() uint16 { return StreamDeckNeo }
+
+func (*deckConfigNeo) NumKeys() int { return 8 }
+
+func (*deckConfigNeo) NumTouchKeys() int { return 2 }
+
+func (d *deckConfigNeo) ResetToLogo() error {
+	if _, err := d.dev.SendFeatureReport([]byte{
+		0x03,
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}); err != nil {
+		return fmt.Errorf("sending feature report: %w", err)
+	}
+
+	return nil
+}
+
+func (d *deckConfigNeo) SetBrightness(pct int) error {
+	if pct < 0 || pct > 100 {
+		return fmt.Errorf("percentage %d out of bounds 0-100", pct)
+	}
+
+	if _, err := d.dev.SendFeatureReport([]byte{
+		0x03, 0x08, byte(pct), 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}); err != nil {
+		return fmt.Errorf("sending feature report: %w", err)
+	}
+
+	return nil
+}
+
+func (d *deckConfigNeo) SetDevice(dev Transport) { d.dev = dev }
+
+func (d *deckConfigNeo) SetTouchKeyColor(touchKeyIdx int, col color.RGBA) error {
+	if touchKeyIdx >= d.NumTouchKeys() || touchKeyIdx < 0 {
+		return fmt.Errorf("touch key index %d out of bounds", touchKeyIdx)
+	}
+
+	r := make([]byte, 32)
+	r[0] = 0x03
+	r[1] = 0x06
+	r[2] = byte(d.NumKeys() + touchKeyIdx) //#nosec:G115 // touchKeyIdx is guarded to safe values
+	r[3] = col.R
+	r[4] = col.G
+	r[5] = col.B
+
+	if _, err := d.dev.SendFeatureReport(r); err != nil {
+		return fmt.Errorf("sending feature report: %w", err)
+	}
+
+	return nil
+}
+
+func (*deckConfigNeo) TransformKeyIndex(keyIdx int) int { return keyIdx }
+
+func (d *deckConfigNeo) WriteEncoded(keyIdx int, payload []byte) error {
+	if keyIdx >= d.NumKeys() || keyIdx < 0 {
+		return fmt.Errorf("key index %d out of bounds", keyIdx)
+	}
+
+	return d.writePayload(0x07, byte(keyIdx), payload) //#nosec:G115 // keyIdx is guarded to safe values
+}
+
+func (d *deckConfigNeo) writePayload(command, target byte, payload []byte) error {
+	d.writeLock.Lock()
+	defer d.writeLock.Unlock()
+
+	buf := bytes.NewBuffer(payload)
+
+	var partIndex int16
+	for buf.Len() > 0 {
+		chunk := make([]byte, deckNeoMaxPacketSize-deckNeoHeaderSize)
+		n, err := buf.Read(chunk)
+		if err != nil {
+			return fmt.Errorf("reading image chunk: %w", err)
+		}
+
+		var last uint8
+		if n < deckNeoMaxPacketSize-deckNeoHeaderSize || buf.Len() == 0 {
+			last = 1
+		}
+
+		tbuf := new(bytes.Buffer)
+		tbuf.Write([]byte{0x02, command, target, last})
+		_ = binary.Write(tbuf, binary.LittleEndian, int16(n)) //#nosec:G115 // guarded to safe values
+		_ = binary.Write(tbuf, binary.LittleEndian, partIndex)
+		tbuf.Write(chunk)
+
+		if _, err = d.dev.Write(tbuf.Bytes()); err != nil {
+			return fmt.Errorf("sending image chunk: %w", err)
+		}
+
+		partIndex++
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package streamdeck
+
+//revive:disable:add-constant // many numbers with single use or only protocol value
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"sync"
+
+	"github.com/disintegration/imaging"
+	"golang.org/x/image/bmp"
+)
+
+const (
+	deckOriginalMaxPacketSize = 8191
+	deckOriginalHeaderSize    = 16
+	deckOriginalImagePages    = 2
+)
+
+type deckConfigOriginal struct {
+	dev       Transport
+	writeLock sync.Mutex
+
+	keyState []EventType
+}
+
+func newDeckConfigOriginal() deckConfig {
+	d := &deckConfigOriginal{}
+	d.keyState = make([]EventType, d.NumKeys())
+
+	return d
+}
+
+func (d *deckConfigOriginal) ClearAllKeys() error {
+	for i := 0; i < d.NumKeys(); i++ {
+		if err := d.ClearKey(i); err != nil {
+			return fmt.Errorf("clearing key: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (d *deckConfigOriginal) ClearKey(keyIdx int) error {
+	return d.FillColor(keyIdx, color.RGBA{0x0, 0x0, 0x0, 0xff})
+}
+
+func (d *deckConfigOriginal) FillColor(keyIdx int, col color.RGBA) error {
+	img := image.NewRGBA(image.Rect(0, 0, d.IconSize(), d.IconSize()))
+
+	for x := 0; x < d.IconSize(); x++ {
+		for y := 0; y < d.IconSize(); y++ {
+			img.Set(x, y, col)
+		}
+	}
+
+	return d.FillImage(keyIdx, img)
+}
+
+func (*deckConfigOriginal) EncodeImage(img image.Image) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	// We need to rotate the image or it will be presented upside down
+	rimg := imaging.Rotate180(img)
+
+	if err := bmp.Encode(buf, rimg); err != nil {
+		return nil, fmt.Errorf("encoding bmp: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (d *deckConfigOriginal) FillImage(keyIdx int, img image.Image) error {
+	payload, err := d.EncodeImage(img)
+	if err != nil {
+		return err
+	}
+
+	return d.WriteEncoded(keyIdx, payload)
+}
+
+func (d *deckConfigOriginal) FillPanel(img image.RGBA) error {
+	if img.Bounds().Size().X < d.KeyColumns()*d.IconSize() || img.Bounds().Size().Y < d.KeyRows()*d.IconSize() {
+		return fmt.Errorf("image is too small")
+	}
+
+	for k := 0; k < d.NumKeys(); k++ {
+		var (
+			ky = k / d.KeyColumns()
+			kx = k % d.KeyColumns()
+		)
+
+		if err := d.FillImage(k, img.SubImage(image.Rect(kx*d.IconSize(), ky*d.IconSize(), (kx+1)*d.IconSize(), (ky+1)*d.IconSize()))); err != nil {
+			return fmt.Errorf("setting key image: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (d *deckConfigOriginal) GetFimwareVersion() (string, error) {
+	fw := make([]byte, 17)
+	fw[0] = 4
+
+	if _, err := d.dev.GetFeatureReport(fw); err != nil {
+		return "", fmt.Errorf("getting feature report: %w", err)
+	}
+
+	return strings.TrimRight(string(fw[5:]), "\x00"), nil
+}
+
+func (d *deckConfigOriginal) IconBytes() int { return d.IconSize() * d.IconSize() * 3 }
+
+func (*deckConfigOriginal) IconSize() int { return 72 }
+
+func (*deckConfigOriginal) KeyColumns() int { return 5 }
+
+func (*deckConfigOriginal) KeyDataOffset() int { return 1 }
+
+func (*deckConfigOriginal) KeyDirection() keyDirection { return keyDirectionRTL }
+
+func (*deckConfigOriginal) KeyRows() int { return 3 }
+
+func (*deckConfigOriginal) Model() uint16 { return StreamDeckOriginal }
+
+func (*deckConfigOriginal) NumKeys() int { return 15 }
+
+func (d *deckConfigOriginal) ResetToLogo() error {
+	r := make([]byte, 17)
+	r[0] = 0x0b
+	r[1] = 0x63
+
+	if _, err := d.dev.SendFeatureReport(r); err != nil {
+		return fmt.Errorf("sending feature report: %w", err)
+	}
+
+	return nil
+}
+
+func (d *deckConfigOriginal) SetBrightness(pct int) error {
+	if pct < 0 || pct > 100 {
+		return fmt.Errorf("percentage %d out of bounds 0-100", pct)
+	}
+
+	r := make([]byte, 17)
+	r[0] = 0x05
+	r[1] = 0x55
+	r[2] = 0xaa
+	r[3] = 0xd1
+	r[4] = 0x01
+	r[5] = byte(pct)
+
+	if _, err := d.dev.SendFeatureReport(r); err != nil {
+		return fmt.Errorf("sending feature report: %w", err)
+	}
+
+	return nil
+}
+
+func (d *deckConfigOriginal) SetDevice(dev Transport) { d.dev = dev }
+
+// TransformKeyIndex mirrors the key index within its row as the
+// original deck numbers its keys from right to left. The transform
+// is its own inverse and therefore used in both directions.
+func (d *deckConfigOriginal) TransformKeyIndex(keyIdx int) int {
+	col := keyIdx % d.KeyColumns()
+	return keyIdx - col + (d.KeyColumns() - 1 - col)
+}
+
+func (d *deckConfigOriginal) WriteEncoded(keyIdx int, payload []byte) error {
+	if keyIdx >= d.NumKeys() || keyIdx < 0 {
+		return fmt.Errorf("key index %d out of bounds", keyIdx)
+	}
+
+	d.writeLock.Lock()
+	defer d.writeLock.Unlock()
+
+	buf := bytes.NewBuffer(payload)
+
+	// The original deck expects the image split into two halves,
+	// each transmitted in a packet padded to the full packet size
+	pageSize := (buf.Len() + deckOriginalImagePages - 1) / deckOriginalImagePages
+
+	var pageNumber int
+	for buf.Len() > 0 {
+		chunk := buf.Next(pageSize)
+
+		var last uint8
+		if buf.Len() == 0 {
+			last = 1
+		}
+
+		packet := make([]byte, deckOriginalMaxPacketSize)
+		packet[0] = 0x02
+		packet[1] = 0x01
+		packet[2] = byte(pageNumber + 1) //#nosec:G115 // only two pages
+		packet[4] = last
+		packet[5] = byte(d.TransformKeyIndex(keyIdx) + 1) //#nosec:G115 // keyIdx is guarded to safe values
+		copy(packet[deckOriginalHeaderSize:], chunk)
+
+		if _, err := d.dev.Write(packet); err != nil {
+			return fmt.Errorf("sending image chunk: %w", err)
+		}
+
+		pageNumber++
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package streamdeck
+
+//revive:disable:add-constant // many numbers with single use or only protocol value
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// deckConfigPedal handles the StreamDeck Pedal which has three keys
+// without any display: all display related functions report the
+// device not to support them.
+type deckConfigPedal struct {
+	dev Transport
+
+	keyState []EventType
+}
+
+func newDeckConfigPedal() deckConfig {
+	d := &deckConfigPedal{}
+	d.keyState = make([]EventType, d.NumKeys())
+
+	return d
+}
+
+func (*deckConfigPedal) ClearAllKeys() error { return ErrNotSupported }
+
+func (*deckConfigPedal) ClearKey(int) error { return ErrNotSupported }
+
+func (*deckConfigPedal) EncodeImage(image.Image) ([]byte, error) { return nil, ErrNotSupported }
+
+func (*deckConfigPedal) FillColor(int, color.RGBA) error { return ErrNotSupported }
+
+func (*deckConfigPedal) FillImage(int, image.Image) error { return ErrNotSupported }
+
+func (*deckConfigPedal) FillPanel(image.RGBA) error { return ErrNotSupported }
+
+func (d *deckConfigPedal) GetFimwareVersion() (string, error) {
+	fw := make([]byte, 32)
+	fw[0] = 5
+
+	if _, err := d.dev.GetFeatureReport(fw); err != nil {
+		return "", fmt.Errorf("getting feature report: %w", err)
+	}
+
+	return strings.TrimRight(string(fw[6:]), "\x00"), nil
+}
+
+func (*deckConfigPedal) IconBytes() int { return 0 }
+
+// IconSize returns zero as the pedal has no display
+func (*deckConfigPedal) IconSize() int { return 0 }
+
+func (*deckConfigPedal) KeyColumns() int { return 3 }
+
+func (*deckConfigPedal) KeyDataOffset() int { return 4 }
+
+func (*deckConfigPedal) KeyDirection() keyDirection { return keyDirectionLTR }
+
+func (*deckConfigPedal) KeyRows() int { return 1 }
+
+func (*deckConfigPedal) Model() uint16 { return StreamDeckPedal }
+
+func (*deckConfigPedal) NumKeys() int { return 3 }
+
+func (*deckConfigPedal) ResetToLogo() error { return ErrNotSupported }
+
+func (*deckConfigPedal) SetBrightness(int) error { return ErrNotSupported }
+
+func (d *deckConfigPedal) SetDevice(dev Transport) { d.dev = dev }
+
+func (*deckConfigPedal) TransformKeyIndex(keyIdx int) int { return keyIdx }
+
+func (*deckConfigPedal) WriteEncoded(int, []byte) error { return ErrNotSupported }
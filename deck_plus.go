@@ -0,0 +1,331 @@
+package streamdeck
+
+//revive:disable:add-constant // many numbers with single use or only protocol value
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"strings"
+	"sync"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	deckPlusMaxPacketSize   = 1024
+	deckPlusHeaderSize      = 8
+	deckPlusLCDHeaderSize   = 16
+	deckPlusLCDWidth        = 800
+	deckPlusLCDHeight       = 100
+	deckPlusReportKeys      = 0x00
+	deckPlusReportTouch     = 0x02
+	deckPlusReportDial      = 0x03
+	deckPlusDialRotate      = 0x01
+	deckPlusDialPush        = 0x00
+	deckPlusTouchShort      = 0x01
+	deckPlusTouchLong       = 0x02
+	deckPlusTouchDrag       = 0x03
+	deckPlusDialStateOffset = 5
+)
+
+type deckConfigPlus struct {
+	dev       Transport
+	writeLock sync.Mutex
+
+	keyState  []EventType
+	dialState []bool
+}
+
+func newDeckConfigPlus() deckConfig {
+	d := &deckConfigPlus{}
+	d.keyState = make([]EventType, d.NumKeys())
+	d.dialState = make([]bool, d.NumDials())
+
+	return d
+}
+
+func (d *deckConfigPlus) ClearAllKeys() error {
+	for i := 0; i < d.NumKeys(); i++ {
+		if err := d.ClearKey(i); err != nil {
+			return fmt.Errorf("clearing key: %w", err)
+		}
+	}
+	return nil
+}
+
+func (d *deckConfigPlus) ClearKey(keyIdx int) error {
+	return d.FillColor(keyIdx, color.RGBA{0x0, 0x0, 0x0, 0xff})
+}
+
+func (d *deckConfigPlus) DecodeInputReport(buf []byte) (evts []Event, isKeyReport bool) {
+	switch buf[1] {
+	case deckPlusReportKeys:
+		return nil, true
+
+	case deckPlusReportTouch:
+		evt := Event{
+			Point: image.Pt(int(binary.LittleEndian.Uint16(buf[6:8])), int(binary.LittleEndian.Uint16(buf[8:10]))),
+		}
+
+		switch buf[4] {
+		case deckPlusTouchShort:
+			evt.Type = EventTypeTouchTap
+		case deckPlusTouchLong:
+			evt.Type = EventTypeTouchLongPress
+		case deckPlusTouchDrag:
+			evt.Type = EventTypeTouchSwipe
+			evt.End = image.Pt(int(binary.LittleEndian.Uint16(buf[10:12])), int(binary.LittleEndian.Uint16(buf[12:14])))
+		default:
+			return nil, false
+		}
+
+		return []Event{evt}, false
+
+	case deckPlusReportDial:
+		for i := 0; i < d.NumDials(); i++ {
+			value := buf[deckPlusDialStateOffset+i]
+
+			switch buf[4] {
+			case deckPlusDialRotate:
+				if value == 0 {
+					continue
+				}
+
+				evts = append(evts, Event{Type: EventTypeDialRotate, Dial: i, Delta: int(int8(value))}) //#nosec:G115 // value is a signed byte
+
+			case deckPlusDialPush:
+				pressed := value != 0
+				if d.dialState[i] == pressed {
+					continue
+				}
+				d.dialState[i] = pressed
+
+				evtType := EventTypeDialUp
+				if pressed {
+					evtType = EventTypeDialDown
+				}
+
+				evts = append(evts, Event{Type: evtType, Dial: i})
+			}
+		}
+
+		return evts, false
+	}
+
+	return nil, false
+}
+
+func (d *deckConfigPlus) FillColor(keyIdx int, col color.RGBA) error {
+	img := image.NewRGBA(image.Rect(0, 0, d.IconSize(), d.IconSize()))
+
+	for x := 0; x < d.IconSize(); x++ {
+		for y := 0; y < d.IconSize(); y++ {
+			img.Set(x, y, col)
+		}
+	}
+
+	return d.FillImage(keyIdx, img)
+}
+
+func (*deckConfigPlus) EncodeImage(img image.Image) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	if err := jpeg.Encode(buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		return nil, fmt.Errorf("encoding jpeg: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (d *deckConfigPlus) FillImage(keyIdx int, img image.Image) error {
+	payload, err := d.EncodeImage(img)
+	if err != nil {
+		return err
+	}
+
+	return d.WriteEncoded(keyIdx, payload)
+}
+
+func (d *deckConfigPlus) FillLCDRegion(rect image.Rectangle, img image.Image) error {
+	if rect.Empty() || !rect.In(image.Rectangle{Max: d.LCDSize()}) {
+		return fmt.Errorf("region %s out of LCD bounds", rect)
+	}
+
+	if img.Bounds().Size() != rect.Size() {
+		img = imaging.PasteCenter(
+			imaging.New(rect.Dx(), rect.Dy(), color.RGBA{0x0, 0x0, 0x0, 0xff}),
+			imaging.Fit(img, rect.Dx(), rect.Dy(), imaging.Lanczos),
+		)
+	}
+
+	d.writeLock.Lock()
+	defer d.writeLock.Unlock()
+
+	buf := new(bytes.Buffer)
+
+	if err := jpeg.Encode(buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		return fmt.Errorf("encoding jpeg: %w", err)
+	}
+
+	var partIndex uint16
+	for buf.Len() > 0 {
+		chunk := make([]byte, deckPlusMaxPacketSize-deckPlusLCDHeaderSize)
+		n, err := buf.Read(chunk)
+		if err != nil {
+			return fmt.Errorf("reading image chunk: %w", err)
+		}
+
+		var last uint8
+		if buf.Len() == 0 {
+			last = 1
+		}
+
+		header := make([]byte, deckPlusLCDHeaderSize)
+		header[0] = 0x02
+		header[1] = 0x0c
+		binary.LittleEndian.PutUint16(header[2:4], uint16(rect.Min.X)) //#nosec:G115 // guarded by LCD bounds
+		binary.LittleEndian.PutUint16(header[4:6], uint16(rect.Min.Y)) //#nosec:G115 // guarded by LCD bounds
+		binary.LittleEndian.PutUint16(header[6:8], uint16(rect.Dx()))  //#nosec:G115 // guarded by LCD bounds
+		binary.LittleEndian.PutUint16(header[8:10], uint16(rect.Dy())) //#nosec:G115 // guarded by LCD bounds
+		header[10] = last
+		binary.LittleEndian.PutUint16(header[11:13], partIndex)
+		binary.LittleEndian.PutUint16(header[13:15], uint16(n)) //#nosec:G115 // guarded by packet size
+
+		if _, err = d.dev.Write(append(header, chunk...)); err != nil {
+			return fmt.Errorf("sending image chunk: %w", err)
+		}
+
+		partIndex++
+	}
+
+	return nil
+}
+
+func (d *deckConfigPlus) FillPanel(img image.RGBA) error {
+	if img.Bounds().Size().X < d.KeyColumns()*d.IconSize() || img.Bounds().Size().Y < d.KeyRows()*d.IconSize() {
+		return fmt.Errorf("image is too small")
+	}
+
+	for k := 0; k < d.NumKeys(); k++ {
+		var (
+			ky = k / d.KeyColumns()
+			kx = k % d.KeyColumns()
+		)
+
+		if err := d.FillImage(k, img.SubImage(image.Rect(kx*d.IconSize(), ky*d.IconSize(), (kx+1)*d.IconSize(), (ky+1)*d.IconSize()))); err != nil {
+			return fmt.Errorf("setting key image: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (d *deckConfigPlus) GetFimwareVersion() (string, error) {
+	fw := make([]byte, 32)
+	fw[0] = 5
+
+	_, err := d.dev.GetFeatureReport(fw)
+	if err != nil {
+		return "", fmt.Errorf("getting feature report: %w", err)
+	}
+
+	return strings.TrimRight(string(fw[6:]), "\x00"), nil
+}
+
+func (d *deckConfigPlus) IconBytes() int { return d.IconSize() * d.IconSize() * 3 }
+
+func (*deckConfigPlus) IconSize() int { return 120 }
+
+func (*deckConfigPlus) KeyColumns() int { return 4 }
+
+func (*deckConfigPlus) KeyDataOffset() int { return 4 }
+
+func (*deckConfigPlus) KeyDirection() keyDirection { return keyDirectionLTR }
+
+func (*deckConfigPlus) KeyRows() int { return 2 }
+
+func (*deckConfigPlus) LCDSize() image.Point { return image.Pt(deckPlusLCDWidth, deckPlusLCDHeight) }
+
+func (*deckConfigPlus) Model() uint16 { return StreamDeckPlus }
+
+func (*deckConfigPlus) NumDials() int { return 4 }
+
+func (*deckConfigPlus) NumKeys() int { return 8 }
+
+func (d *deckConfigPlus) ResetToLogo() error {
+	if _, err := d.dev.SendFeatureReport([]byte{
+		0x03,
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}); err != nil {
+		return fmt.Errorf("sending feature report: %w", err)
+	}
+
+	return nil
+}
+
+func (d *deckConfigPlus) SetBrightness(pct int) error {
+	if pct < 0 || pct > 100 {
+		return fmt.Errorf("percentage %d out of bounds 0-100", pct)
+	}
+
+	if _, err := d.dev.SendFeatureReport([]byte{
+		0x03, 0x08, byte(pct), 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}); err != nil {
+		return fmt.Errorf("sending feature report: %w", err)
+	}
+
+	return nil
+}
+
+func (d *deckConfigPlus) SetDevice(dev Transport) { d.dev = dev }
+
+func (*deckConfigPlus) TransformKeyIndex(keyIdx int) int { return keyIdx }
+
+func (d *deckConfigPlus) WriteEncoded(keyIdx int, payload []byte) error {
+	if keyIdx >= d.NumKeys() || keyIdx < 0 {
+		return fmt.Errorf("key index %d out of bounds", keyIdx)
+	}
+
+	d.writeLock.Lock()
+	defer d.writeLock.Unlock()
+
+	buf := bytes.NewBuffer(payload)
+
+	var partIndex int16
+	for buf.Len() > 0 {
+		chunk := make([]byte, deckPlusMaxPacketSize-deckPlusHeaderSize)
+		n, err := buf.Read(chunk)
+		if err != nil {
+			return fmt.Errorf("reading image chunk: %w", err)
+		}
+
+		var last uint8
+		if n < deckPlusMaxPacketSize-deckPlusHeaderSize || buf.Len() == 0 {
+			last = 1
+		}
+
+		tbuf := new(bytes.Buffer)
+		tbuf.Write([]byte{0x02, 0x07, byte(keyIdx), last})    //#nosec:G115 // keyIdx is guarded to safe values
+		_ = binary.Write(tbuf, binary.LittleEndian, int16(n)) //#nosec:G115 // guarded to safe values
+		_ = binary.Write(tbuf, binary.LittleEndian, partIndex)
+		tbuf.Write(chunk)
+
+		if _, err = d.dev.Write(tbuf.Bytes()); err != nil {
+			return fmt.Errorf("sending image chunk: %w", err)
+		}
+
+		partIndex++
+	}
+
+	return nil
+}
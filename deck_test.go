@@ -0,0 +1,456 @@
+package streamdeck
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/bmp"
+)
+
+func testKeyImage(size int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for x := 0; x < size; x++ {
+		for y := 0; y < size; y++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 0x80, 0xff}) //#nosec:G115 // test data
+		}
+	}
+
+	return img
+}
+
+func TestPacketFormatJPEG(t *testing.T) {
+	t.Parallel()
+
+	for _, model := range []uint16{StreamDeckOriginalV2, StreamDeckMK2, StreamDeckXL, StreamDeckXLV2} {
+		t.Run(DeckToName[model], func(t *testing.T) {
+			t.Parallel()
+
+			client, fake := newFakeClient(t, model)
+			img := testKeyImage(client.IconSize())
+
+			expected := new(bytes.Buffer)
+			require.NoError(t, jpeg.Encode(expected, imaging.Rotate180(img), &jpeg.Options{Quality: 95}))
+
+			require.NoError(t, client.FillImage(3, img))
+
+			writes := fake.Writes()
+			require.Len(t, writes, (expected.Len()+1015)/1016)
+
+			payload := new(bytes.Buffer)
+			for i, w := range writes {
+				require.Len(t, w, 1024)
+
+				isLast := i == len(writes)-1
+				length := int(binary.LittleEndian.Uint16(w[4:6]))
+
+				assert.Equal(t, []byte{0x02, 0x07, 0x03}, w[:3])
+				assert.Equal(t, isLast, w[3] == 1)
+				assert.Equal(t, uint16(i), binary.LittleEndian.Uint16(w[6:8])) //#nosec:G115 // test data
+				if !isLast {
+					assert.Equal(t, 1016, length)
+				}
+
+				payload.Write(w[8 : 8+length])
+			}
+
+			assert.Equal(t, expected.Bytes(), payload.Bytes())
+
+			require.NoError(t, client.SetBrightness(60))
+			require.NoError(t, client.ResetToLogo())
+
+			reports := fake.FeatureReports()
+			require.Len(t, reports, 2)
+			assert.Equal(t, append([]byte{0x03, 0x08, 60}, make([]byte, 29)...), reports[0])
+			assert.Equal(t, append([]byte{0x03, 0x02}, make([]byte, 30)...), reports[1])
+		})
+	}
+}
+
+func TestPacketFormatMini(t *testing.T) {
+	t.Parallel()
+
+	for _, model := range []uint16{StreamDeckMini, StreamDeckMiniV2} {
+		t.Run(DeckToName[model], func(t *testing.T) {
+			t.Parallel()
+
+			client, fake := newFakeClient(t, model)
+			img := testKeyImage(client.IconSize())
+
+			expected := new(bytes.Buffer)
+			require.NoError(t, bmp.Encode(expected, imaging.Transpose(img)))
+
+			require.NoError(t, client.FillImage(4, img))
+
+			writes := fake.Writes()
+			require.Len(t, writes, (expected.Len()+1007)/1008)
+
+			payload := new(bytes.Buffer)
+			for i, w := range writes {
+				require.Len(t, w, 1024)
+
+				var last byte
+				if i == len(writes)-1 {
+					last = 1
+				}
+
+				assert.Equal(t, []byte{
+					0x02, 0x01, byte(i), 0x00, last, 0x05, 0x00, 0x00, //#nosec:G115 // test data
+					0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				}, w[:16])
+
+				payload.Write(w[16:])
+			}
+
+			assert.Equal(t, expected.Bytes(), payload.Bytes()[:expected.Len()])
+
+			require.NoError(t, client.SetBrightness(60))
+			require.NoError(t, client.ResetToLogo())
+
+			reports := fake.FeatureReports()
+			require.Len(t, reports, 2)
+			assert.Equal(t, append([]byte{0x05, 0x55, 0xaa, 0xd1, 0x01, 60}, make([]byte, 11)...), reports[0])
+			assert.Equal(t, append([]byte{0x0b, 0x63}, make([]byte, 15)...), reports[1])
+		})
+	}
+}
+
+func TestPacketFormatOriginal(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newFakeClient(t, StreamDeckOriginal)
+	img := testKeyImage(client.IconSize())
+
+	expected := new(bytes.Buffer)
+	require.NoError(t, bmp.Encode(expected, imaging.Rotate180(img)))
+	require.Equal(t, 54+72*72*3, expected.Len())
+
+	// Key 1 is the second key from the left, which is the fourth key
+	// from the right as addressed by the device
+	require.NoError(t, client.FillImage(1, img))
+
+	writes := fake.Writes()
+	require.Len(t, writes, 2)
+
+	half := expected.Len() / 2
+	for i, w := range writes {
+		require.Len(t, w, 8191)
+
+		assert.Equal(t, []byte{
+			0x02, 0x01, byte(i + 1), 0x00, byte(i), 0x04, 0x00, 0x00, //#nosec:G115 // test data
+			0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		}, w[:16])
+
+		assert.Equal(t, expected.Bytes()[i*half:(i+1)*half], w[16:16+half])
+		assert.Equal(t, make([]byte, 8191-16-half), w[16+half:])
+	}
+
+	assert.NotNil(t, fake.KeyImage(1))
+
+	require.NoError(t, client.SetBrightness(60))
+	require.NoError(t, client.ResetToLogo())
+
+	reports := fake.FeatureReports()
+	require.Len(t, reports, 2)
+	assert.Equal(t, append([]byte{0x05, 0x55, 0xaa, 0xd1, 0x01, 60}, make([]byte, 11)...), reports[0])
+	assert.Equal(t, append([]byte{0x0b, 0x63}, make([]byte, 15)...), reports[1])
+}
+
+func TestKeyOrderOriginal(t *testing.T) {
+	t.Parallel()
+
+	cfg := newDeckConfigOriginal()
+	for logical, device := range map[int]int{0: 4, 1: 3, 2: 2, 4: 0, 5: 9, 9: 5, 10: 14, 14: 10} {
+		assert.Equal(t, device, cfg.TransformKeyIndex(logical))
+		assert.Equal(t, logical, cfg.TransformKeyIndex(device))
+	}
+
+	client, fake := newFakeClient(t, StreamDeckOriginal)
+	evts := client.Subscribe(t.Context())
+
+	// Inject the raw report: the key in the top-right corner is the
+	// first key in the device report
+	report := make([]byte, 16)
+	report[1] = byte(EventTypeDown)
+	fake.reports <- report
+
+	select {
+	case evt := <-evts:
+		assert.Equal(t, Event{Key: 4, Type: EventTypeDown}, withoutTiming(evt)[0])
+	case <-time.After(testEventTimeout):
+		t.Fatal("timeout waiting for event")
+	}
+}
+
+func TestPacketFormatPlus(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newFakeClient(t, StreamDeckPlus)
+
+	// Keys are sent without rotation
+	img := testKeyImage(client.IconSize())
+	expected := new(bytes.Buffer)
+	require.NoError(t, jpeg.Encode(expected, img, &jpeg.Options{Quality: 95}))
+
+	require.NoError(t, client.FillImage(5, img))
+
+	payload := new(bytes.Buffer)
+	for _, w := range fake.Writes() {
+		require.Len(t, w, 1024)
+		assert.Equal(t, []byte{0x02, 0x07, 0x05}, w[:3])
+		payload.Write(w[8 : 8+int(binary.LittleEndian.Uint16(w[4:6]))])
+	}
+	assert.Equal(t, expected.Bytes(), payload.Bytes())
+
+	// LCD regions carry their position and size
+	region := client.DialRegion(2)
+	require.Equal(t, image.Rect(400, 0, 600, 100), region)
+
+	lcdImg := image.NewRGBA(image.Rectangle{Max: region.Size()})
+	for x := 0; x < region.Dx(); x++ {
+		for y := 0; y < region.Dy(); y++ {
+			lcdImg.Set(x, y, color.RGBA{0x0, 0xff, 0x0, 0xff})
+		}
+	}
+
+	expected.Reset()
+	require.NoError(t, jpeg.Encode(expected, lcdImg, &jpeg.Options{Quality: 95}))
+
+	nKeyWrites := len(fake.Writes())
+	require.NoError(t, client.FillLCDRegion(region, lcdImg))
+
+	writes := fake.Writes()[nKeyWrites:]
+	require.Len(t, writes, (expected.Len()+1007)/1008)
+
+	payload.Reset()
+	for i, w := range writes {
+		require.Len(t, w, 1024)
+
+		var last byte
+		if i == len(writes)-1 {
+			last = 1
+		}
+
+		length := int(binary.LittleEndian.Uint16(w[13:15]))
+		assert.Equal(t, []byte{
+			0x02, 0x0c, 0x90, 0x01, 0x00, 0x00, 0xc8, 0x00,
+			0x64, 0x00, last, byte(i), 0x00, //#nosec:G115 // test data
+		}, w[:13])
+		assert.Equal(t, byte(0x00), w[15])
+
+		payload.Write(w[16 : 16+length])
+	}
+	assert.Equal(t, expected.Bytes(), payload.Bytes())
+
+	lcd := fake.LCDImage()
+	require.NotNil(t, lcd)
+	assertColorNear(t, color.RGBA{0x0, 0xff, 0x0, 0xff}, lcd.At(500, 50))
+	assertColorNear(t, color.RGBA{0x0, 0x0, 0x0, 0x0}, lcd.At(100, 50))
+
+	assert.Error(t, client.FillLCDRegion(image.Rect(700, 0, 900, 100), lcdImg))
+}
+
+func TestInputEventsPlus(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newFakeClient(t, StreamDeckPlus)
+	evts := client.Subscribe(t.Context())
+
+	assert.Equal(t, 4, client.NumDials())
+	assert.Equal(t, image.Pt(800, 100), client.LCDSize())
+
+	require.NoError(t, fake.Press(6))
+	require.NoError(t, fake.RotateDial(1, -3))
+	require.NoError(t, fake.RotateDial(3, 2))
+	require.NoError(t, fake.PressDial(0))
+	require.NoError(t, fake.ReleaseDial(0))
+	require.NoError(t, fake.Touch(EventTypeTouchTap, image.Pt(120, 40), image.Point{}))
+	require.NoError(t, fake.Touch(EventTypeTouchSwipe, image.Pt(500, 50), image.Pt(100, 60)))
+
+	for _, expected := range []Event{
+		{Key: 6, Type: EventTypeDown},
+		{Type: EventTypeDialRotate, Dial: 1, Delta: -3},
+		{Type: EventTypeDialRotate, Dial: 3, Delta: 2},
+		{Type: EventTypeDialDown, Dial: 0},
+		{Type: EventTypeDialUp, Dial: 0},
+		{Type: EventTypeTouchTap, Point: image.Pt(120, 40)},
+		{Type: EventTypeTouchSwipe, Point: image.Pt(500, 50), End: image.Pt(100, 60)},
+	} {
+		select {
+		case evt := <-evts:
+			assert.Equal(t, expected, withoutTiming(evt)[0])
+		case <-time.After(testEventTimeout):
+			t.Fatal("timeout waiting for event")
+		}
+	}
+}
+
+func TestNoLCDSupport(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newFakeClient(t, StreamDeckXL)
+
+	assert.Equal(t, 0, client.NumDials())
+	assert.Equal(t, image.Point{}, client.LCDSize())
+	assert.Equal(t, image.Rectangle{}, client.DialRegion(0))
+	require.ErrorIs(t, client.FillLCD(image.NewRGBA(image.Rect(0, 0, 1, 1))), ErrNotSupported)
+	require.ErrorIs(t, fake.RotateDial(0, 1), ErrNotSupported)
+	assert.Nil(t, fake.LCDImage())
+	assert.True(t, client.HasDisplay())
+	assert.False(t, client.HasInfoBar())
+	assert.Equal(t, 0, client.NumTouchKeys())
+	require.ErrorIs(t, client.FillInfoBar(image.NewRGBA(image.Rect(0, 0, 1, 1))), ErrNotSupported)
+}
+
+func TestPedal(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newFakeClient(t, StreamDeckPedal)
+	evts := client.Subscribe(t.Context())
+
+	assert.False(t, client.HasDisplay())
+	assert.False(t, client.HasInfoBar())
+	assert.Equal(t, 3, client.NumKeys())
+	assert.Equal(t, 0, client.IconSize())
+
+	require.ErrorIs(t, client.FillImage(0, testKeyImage(72)), ErrNotSupported)
+	require.ErrorIs(t, client.FillColor(0, color.RGBA{0xff, 0x0, 0x0, 0xff}), ErrNotSupported)
+	require.ErrorIs(t, client.SetBrightness(50), ErrNotSupported)
+	require.ErrorIs(t, client.ResetToLogo(), ErrNotSupported)
+	assert.Empty(t, fake.Writes())
+	assert.Empty(t, fake.FeatureReports())
+
+	firmware, err := client.GetFimwareVersion()
+	require.NoError(t, err)
+	assert.Equal(t, fakeDeckDefaultFirmware, firmware)
+
+	require.NoError(t, fake.Press(2))
+	require.NoError(t, fake.Release(2))
+
+	for _, expected := range []Event{
+		{Key: 2, Type: EventTypeDown},
+		{Key: 2, Type: EventTypeUp},
+	} {
+		select {
+		case evt := <-evts:
+			assert.Equal(t, expected, withoutTiming(evt)[0])
+		case <-time.After(testEventTimeout):
+			t.Fatal("timeout waiting for event")
+		}
+	}
+}
+
+func TestNeo(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newFakeClient(t, StreamDeckNeo)
+	evts := client.Subscribe(t.Context())
+
+	assert.True(t, client.HasDisplay())
+	assert.True(t, client.HasInfoBar())
+	assert.Equal(t, 2, client.NumTouchKeys())
+	assert.Equal(t, image.Pt(248, 58), client.InfoBarSize())
+
+	// Info bar is rotated like the keys and carries its page index
+	bar := image.NewRGBA(image.Rectangle{Max: client.InfoBarSize()})
+	for x := 0; x < bar.Rect.Dx(); x++ {
+		for y := 0; y < bar.Rect.Dy(); y++ {
+			if x < bar.Rect.Dx()/2 {
+				bar.Set(x, y, color.RGBA{0xff, 0x0, 0x0, 0xff})
+			} else {
+				bar.Set(x, y, color.RGBA{0x0, 0x0, 0xff, 0xff})
+			}
+		}
+	}
+
+	expected := new(bytes.Buffer)
+	require.NoError(t, jpeg.Encode(expected, imaging.Rotate180(bar), &jpeg.Options{Quality: 95}))
+
+	require.NoError(t, client.FillInfoBar(bar))
+
+	payload := new(bytes.Buffer)
+	for i, w := range fake.Writes() {
+		require.Len(t, w, 1024)
+		assert.Equal(t, []byte{0x02, 0x0b, 0x00}, w[:3])
+		assert.Equal(t, uint16(i), binary.LittleEndian.Uint16(w[6:8])) //#nosec:G115 // test data
+		payload.Write(w[8 : 8+int(binary.LittleEndian.Uint16(w[4:6]))])
+	}
+	assert.Equal(t, expected.Bytes(), payload.Bytes())
+
+	got := fake.InfoBarImage()
+	require.NotNil(t, got)
+	assertColorNear(t, color.RGBA{0xff, 0x0, 0x0, 0xff}, got.At(10, 29))
+	assertColorNear(t, color.RGBA{0x0, 0x0, 0xff, 0xff}, got.At(238, 29))
+	assert.Nil(t, fake.LCDImage())
+
+	// Touch keys follow the regular keys and are lit through LEDs
+	require.NoError(t, client.FillColor(9, color.RGBA{0x10, 0x20, 0x30, 0xff}))
+	assert.Equal(t, color.RGBA{0x10, 0x20, 0x30, 0xff}, fake.TouchKeyColor(1))
+	assert.Equal(t, []byte{0x03, 0x06, 0x09, 0x10, 0x20, 0x30}, fake.FeatureReports()[0][:6])
+
+	require.NoError(t, client.FillImage(8, testKeyImage(client.IconSize())))
+	assertColorNear(t, color.RGBA{47, 47, 0x80, 0xff}, fake.TouchKeyColor(0))
+
+	require.NoError(t, client.ClearKey(9))
+	assert.Equal(t, color.RGBA{0x0, 0x0, 0x0, 0xff}, fake.TouchKeyColor(1))
+	require.Error(t, client.SetTouchKeyColor(2, color.RGBA{}))
+
+	require.NoError(t, fake.Press(3))
+	require.NoError(t, fake.Press(8))
+	require.NoError(t, fake.Release(8))
+
+	for _, expected := range []Event{
+		{Key: 3, Type: EventTypeDown},
+		{Key: 8, Type: EventTypeDown},
+		{Key: 8, Type: EventTypeUp},
+	} {
+		select {
+		case evt := <-evts:
+			assert.Equal(t, expected, withoutTiming(evt)[0])
+		case <-time.After(testEventTimeout):
+			t.Fatal("timeout waiting for event")
+		}
+	}
+}
+
+func TestFillPanelImageGap(t *testing.T) {
+	t.Parallel()
+
+	const gap = 10
+
+	client, fake := newFakeClient(t, StreamDeckMini)
+
+	size := client.PanelSize(gap)
+	assert.Equal(t, image.Pt(3*80+2*gap, 2*80+gap), size)
+
+	// White panel with every key area in its own color
+	panel := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(panel, panel.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	keyColor := func(k int) color.RGBA { return color.RGBA{uint8(k * 40), 0x0, uint8(255 - k*40), 0xff} } //#nosec:G115 // test data
+	for k := range client.NumKeys() {
+		draw.Draw(panel, client.KeyPanelRect(k, gap), image.NewUniform(keyColor(k)), image.Point{}, draw.Src)
+	}
+
+	require.NoError(t, client.FillPanelImage(panel, gap))
+
+	for k := range client.NumKeys() {
+		img := fake.KeyImage(k)
+		require.NotNil(t, img)
+
+		// Neither the bezel gap nor parts of other keys are visible
+		assertColorNear(t, keyColor(k), img.At(1, 1))
+		assertColorNear(t, keyColor(k), img.At(78, 78))
+	}
+
+	pedal, _ := newFakeClient(t, StreamDeckPedal)
+	require.ErrorIs(t, pedal.FillPanelImage(panel, 0), ErrNotSupported)
+}
@@ -13,7 +13,6 @@ import (
 	"sync"
 
 	"github.com/disintegration/imaging"
-	"github.com/sstallion/go-hid"
 )
 
 const (
@@ -22,7 +21,7 @@ const (
 )
 
 type deckConfigXL struct {
-	dev       *hid.Device
+	dev       Transport
 	writeLock sync.Mutex
 
 	keyState []EventType
@@ -60,50 +59,26 @@ func (d *deckConfigXL) FillColor(keyIdx int, col color.RGBA) error {
 	return d.FillImage(keyIdx, img)
 }
 
-func (d *deckConfigXL) FillImage(keyIdx int, img image.Image) error {
-	if keyIdx >= d.NumKeys() || keyIdx < 0 {
-		return fmt.Errorf("key index %d out of bounds", keyIdx)
-	}
-
-	d.writeLock.Lock()
-	defer d.writeLock.Unlock()
-
+func (*deckConfigXL) EncodeImage(img image.Image) ([]byte, error) {
 	buf := new(bytes.Buffer)
 
 	// We need to rotate the image or it will be presented upside down
 	rimg := imaging.Rotate180(img)
 
 	if err := jpeg.Encode(buf, rimg, &jpeg.Options{Quality: 95}); err != nil {
-		return fmt.Errorf("encoding jpeg: %w", err)
+		return nil, fmt.Errorf("encoding jpeg: %w", err)
 	}
 
-	var partIndex int16
-	for buf.Len() > 0 {
-		chunk := make([]byte, deckXLMaxPacketSize-deckXLHeaderSize)
-		n, err := buf.Read(chunk)
-		if err != nil {
-			return fmt.Errorf("reading image chunk: %w", err)
-		}
-
-		var last uint8
-		if n < deckXLMaxPacketSize-deckXLHeaderSize || buf.Len() == 0 {
-			last = 1
-		}
-
-		tbuf := new(bytes.Buffer)
-		tbuf.Write([]byte{0x02, 0x07, byte(keyIdx), last})    //#nosec:G115 // keyIdx is guarded to safe values
-		_ = binary.Write(tbuf, binary.LittleEndian, int16(n)) //#nosec:G115 // guarded to safe values
-		_ = binary.Write(tbuf, binary.LittleEndian, partIndex)
-		tbuf.Write(chunk)
-
-		if _, err = d.dev.Write(tbuf.Bytes()); err != nil {
-			return fmt.Errorf("sending image chunk: %w", err)
-		}
+	return buf.Bytes(), nil
+}
 
-		partIndex++
+func (d *deckConfigXL) FillImage(keyIdx int, img image.Image) error {
+	payload, err := d.EncodeImage(img)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return d.WriteEncoded(keyIdx, payload)
 }
 
 func (d *deckConfigXL) FillPanel(img image.RGBA) error {
@@ -184,5 +159,44 @@ func (d *deckConfigXL) SetBrightness(pct int) error {
 	return nil
 }
 
-func (d *deckConfigXL) SetDevice(dev *hid.Device)      { d.dev = dev }
+func (d *deckConfigXL) SetDevice(dev Transport)        { d.dev = dev }
 func (*deckConfigXL) TransformKeyIndex(keyIdx int) int { return keyIdx }
+
+func (d *deckConfigXL) WriteEncoded(keyIdx int, payload []byte) error {
+	if keyIdx >= d.NumKeys() || keyIdx < 0 {
+		return fmt.Errorf("key index %d out of bounds", keyIdx)
+	}
+
+	d.writeLock.Lock()
+	defer d.writeLock.Unlock()
+
+	buf := bytes.NewBuffer(payload)
+
+	var partIndex int16
+	for buf.Len() > 0 {
+		chunk := make([]byte, deckXLMaxPacketSize-deckXLHeaderSize)
+		n, err := buf.Read(chunk)
+		if err != nil {
+			return fmt.Errorf("reading image chunk: %w", err)
+		}
+
+		var last uint8
+		if n < deckXLMaxPacketSize-deckXLHeaderSize || buf.Len() == 0 {
+			last = 1
+		}
+
+		tbuf := new(bytes.Buffer)
+		tbuf.Write([]byte{0x02, 0x07, byte(keyIdx), last})    //#nosec:G115 // keyIdx is guarded to safe values
+		_ = binary.Write(tbuf, binary.LittleEndian, int16(n)) //#nosec:G115 // guarded to safe values
+		_ = binary.Write(tbuf, binary.LittleEndian, partIndex)
+		tbuf.Write(chunk)
+
+		if _, err = d.dev.Write(tbuf.Bytes()); err != nil {
+			return fmt.Errorf("sending image chunk: %w", err)
+		}
+
+		partIndex++
+	}
+
+	return nil
+}
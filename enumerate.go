@@ -0,0 +1,103 @@
+package streamdeck
+
+import (
+	"errors"
+	"fmt"
+
+	hid "github.com/sstallion/go-hid"
+)
+
+type (
+	// DeviceInfo describes a connected StreamDeck
+	DeviceInfo struct {
+		// Model contains the product ID of the device (see constants
+		// for supported types)
+		Model uint16
+		// Path contains the platform specific HID path of the device
+		Path string
+		// Serial contains the serial number of the device
+		Serial string
+	}
+)
+
+// ErrNotFound is returned when the requested device is not connected
+var ErrNotFound = errors.New("device not found")
+
+// Enumerate lists all connected and supported StreamDecks
+func Enumerate() (out []DeviceInfo, err error) {
+	if err = hid.Enumerate(VendorElgato, hid.ProductIDAny, func(info *hid.DeviceInfo) error {
+		if _, ok := decks[info.ProductID]; !ok {
+			// Is from Elgato but not a supported StreamDeck
+			return nil
+		}
+
+		out = append(out, DeviceInfo{
+			Model:  info.ProductID,
+			Path:   info.Path,
+			Serial: info.SerialNbr,
+		})
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("enumerating devices: %w", err)
+	}
+
+	return out, nil
+}
+
+// Open creates a new Client for the connected StreamDeck having the
+// given serial number
+func Open(serial string) (*Client, error) {
+	infos, err := Enumerate()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, info := range infos {
+		if info.Serial == serial {
+			return info.open()
+		}
+	}
+
+	return nil, fmt.Errorf("serial %q: %w", serial, ErrNotFound)
+}
+
+// OpenPath creates a new Client for the connected StreamDeck at the
+// given HID path
+func OpenPath(path string) (*Client, error) {
+	infos, err := Enumerate()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, info := range infos {
+		if info.Path == path {
+			return info.open()
+		}
+	}
+
+	return nil, fmt.Errorf("path %q: %w", path, ErrNotFound)
+}
+
+// FirmwareVersion opens the device to read its firmware version
+// without starting to listen for events
+func (d DeviceInfo) FirmwareVersion() (string, error) {
+	dev, err := hid.OpenPath(d.Path)
+	if err != nil {
+		return "", fmt.Errorf("opening device: %w", err)
+	}
+	defer dev.Close() //nolint:errcheck // Close of hid.Device never fails
+
+	cfg := decks[d.Model]()
+	cfg.SetDevice(dev)
+
+	return cfg.GetFimwareVersion() //nolint:wrapcheck // wraps internal interface
+}
+
+func (d DeviceInfo) open() (*Client, error) {
+	dev, err := hid.OpenPath(d.Path)
+	if err != nil {
+		return nil, fmt.Errorf("opening device: %w", err)
+	}
+
+	return NewWithTransport(d.Model, dev)
+}
@@ -0,0 +1,691 @@
+package streamdeck
+
+//revive:disable:add-constant // many numbers with single use or only protocol value
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/disintegration/imaging"
+	hid "github.com/sstallion/go-hid"
+	"golang.org/x/image/bmp"
+)
+
+const (
+	fakeDeckDefaultFirmware = "1.00.000"
+	fakeDeckDefaultSerial   = "FAKE00000001"
+	fakeDeckReportBuffer    = 100
+)
+
+type (
+	// FakeDeck is an in-memory Transport emulating a StreamDeck of the
+	// given model. It records all packets written to it, decodes the
+	// image packets back into per-key images and allows to inject key
+	// events to be read by the Client.
+	FakeDeck struct {
+		cfg   deckConfig
+		proto fakeProtocol
+
+		lock           sync.Mutex
+		brightness     int
+		closed         bool
+		dialStates     []byte
+		featureReports [][]byte
+		firmware       string
+		imageBuffers   map[int]*bytes.Buffer
+		images         map[int]image.Image
+		keyStates      []byte
+		lcd            *image.RGBA
+		touchKeyColors []color.RGBA
+		lcdBuffer      *bytes.Buffer
+		logoShown      bool
+		serial         string
+		writes         [][]byte
+
+		gone     chan struct{}
+		goneOnce sync.Once
+		reports  chan []byte
+	}
+
+	fakeProtocol struct {
+		imageHeaderSize int
+		// parseImageHeader returns the (device-side) key index and whether
+		// the packet is the last one for the image
+		parseImageHeader func(header []byte) (keyIdx int, last bool)
+		// payloadLength returns the length of the image data within the
+		// packet payload given the data already buffered for the image
+		payloadLength func(header, payload, buffered []byte) int
+		decodeImage   func(r io.Reader) (image.Image, error)
+		restoreImage  func(img image.Image) image.Image
+
+		// lcdCommand identifies image packets targeting the LCD strip or
+		// info bar instead of a key, zero for devices without such display
+		lcdCommand     byte
+		lcdHeaderSize  int
+		parseLCDHeader func(header []byte) (rect image.Rectangle, last bool, length int)
+		restoreLCD     func(img image.Image) image.Image
+
+		firmwareReportID byte
+		firmwareOffset   int
+		brightnessPrefix []byte
+		resetPrefix      []byte
+	}
+)
+
+var (
+	fakeProtocolJPEG = fakeProtocol{
+		imageHeaderSize: 8,
+		parseImageHeader: func(header []byte) (int, bool) {
+			return int(header[2]), header[3] == 1
+		},
+		payloadLength: func(header, _, _ []byte) int {
+			return int(binary.LittleEndian.Uint16(header[4:6]))
+		},
+		decodeImage:  jpeg.Decode,
+		restoreImage: func(img image.Image) image.Image { return imaging.Rotate180(img) },
+
+		firmwareReportID: 0x05,
+		firmwareOffset:   6,
+		brightnessPrefix: []byte{0x03, 0x08},
+		resetPrefix:      []byte{0x03, 0x02},
+	}
+
+	fakeProtocolMini = fakeProtocol{
+		imageHeaderSize: 16,
+		parseImageHeader: func(header []byte) (int, bool) {
+			return int(header[5]) - 1, header[4] == 1
+		},
+		payloadLength: func(_, payload, _ []byte) int { return len(payload) },
+		decodeImage:   bmp.Decode,
+		restoreImage:  func(img image.Image) image.Image { return imaging.Transpose(img) },
+
+		firmwareReportID: 0x04,
+		firmwareOffset:   5,
+		brightnessPrefix: []byte{0x05, 0x55, 0xaa, 0xd1, 0x01},
+		resetPrefix:      []byte{0x0b, 0x63},
+	}
+
+	fakeProtocolOriginal = fakeProtocol{
+		imageHeaderSize: 16,
+		parseImageHeader: func(header []byte) (int, bool) {
+			return int(header[5]) - 1, header[4] == 1
+		},
+		payloadLength: func(_, payload, buffered []byte) int {
+			// The image is split into two halves padded to the full packet
+			// size, so the BMP file-size from the first half tells how much
+			// of each packet is image data
+			bmpHeader := payload
+			if len(buffered) > 0 {
+				bmpHeader = buffered
+			}
+
+			if len(bmpHeader) < 6 {
+				return len(payload)
+			}
+
+			fileSize := int(binary.LittleEndian.Uint32(bmpHeader[2:6]))
+			if len(buffered) == 0 {
+				return (fileSize + deckOriginalImagePages - 1) / deckOriginalImagePages
+			}
+
+			return fileSize - len(buffered)
+		},
+		decodeImage:  bmp.Decode,
+		restoreImage: func(img image.Image) image.Image { return imaging.Rotate180(img) },
+
+		firmwareReportID: 0x04,
+		firmwareOffset:   5,
+		brightnessPrefix: []byte{0x05, 0x55, 0xaa, 0xd1, 0x01},
+		resetPrefix:      []byte{0x0b, 0x63},
+	}
+
+	fakeProtocolPlus = fakeProtocol{
+		imageHeaderSize: 8,
+		parseImageHeader: func(header []byte) (int, bool) {
+			return int(header[2]), header[3] == 1
+		},
+		payloadLength: func(header, _, _ []byte) int {
+			return int(binary.LittleEndian.Uint16(header[4:6]))
+		},
+		decodeImage:  jpeg.Decode,
+		restoreImage: func(img image.Image) image.Image { return img },
+
+		restoreLCD:    func(img image.Image) image.Image { return img },
+		lcdCommand:    0x0c,
+		lcdHeaderSize: 16,
+		parseLCDHeader: func(header []byte) (image.Rectangle, bool, int) {
+			x := int(binary.LittleEndian.Uint16(header[2:4]))
+			y := int(binary.LittleEndian.Uint16(header[4:6]))
+			w := int(binary.LittleEndian.Uint16(header[6:8]))
+			h := int(binary.LittleEndian.Uint16(header[8:10]))
+
+			return image.Rect(x, y, x+w, y+h), header[10] == 1, int(binary.LittleEndian.Uint16(header[13:15]))
+		},
+
+		firmwareReportID: 0x05,
+		firmwareOffset:   6,
+		brightnessPrefix: []byte{0x03, 0x08},
+		resetPrefix:      []byte{0x03, 0x02},
+	}
+
+	fakeProtocolNeo = fakeProtocol{
+		imageHeaderSize: 8,
+		parseImageHeader: func(header []byte) (int, bool) {
+			return int(header[2]), header[3] == 1
+		},
+		payloadLength: func(header, _, _ []byte) int {
+			return int(binary.LittleEndian.Uint16(header[4:6]))
+		},
+		decodeImage:  jpeg.Decode,
+		restoreImage: func(img image.Image) image.Image { return imaging.Rotate180(img) },
+
+		restoreLCD:    func(img image.Image) image.Image { return imaging.Rotate180(img) },
+		lcdCommand:    0x0b,
+		lcdHeaderSize: 8,
+		parseLCDHeader: func(header []byte) (image.Rectangle, bool, int) {
+			return image.Rect(0, 0, deckNeoInfoBarWidth, deckNeoInfoBarHeight), header[3] == 1, int(binary.LittleEndian.Uint16(header[4:6]))
+		},
+
+		firmwareReportID: 0x05,
+		firmwareOffset:   6,
+		brightnessPrefix: []byte{0x03, 0x08},
+		resetPrefix:      []byte{0x03, 0x02},
+	}
+
+	// fakeProtocolPedal has no display and therefore only knows about
+	// the firmware report
+	fakeProtocolPedal = fakeProtocol{
+		imageHeaderSize: 8,
+		parseImageHeader: func([]byte) (int, bool) {
+			return -1, false
+		},
+
+		firmwareReportID: 0x05,
+		firmwareOffset:   6,
+	}
+
+	fakeProtocols = map[uint16]fakeProtocol{
+		StreamDeckOriginal:   fakeProtocolOriginal,
+		StreamDeckOriginalV2: fakeProtocolJPEG,
+		StreamDeckMK2:        fakeProtocolJPEG,
+		StreamDeckXL:         fakeProtocolJPEG,
+		StreamDeckXLV2:       fakeProtocolJPEG,
+		StreamDeckMini:       fakeProtocolMini,
+		StreamDeckMiniV2:     fakeProtocolMini,
+		StreamDeckPlus:       fakeProtocolPlus,
+		StreamDeckPedal:      fakeProtocolPedal,
+		StreamDeckNeo:        fakeProtocolNeo,
+	}
+)
+
+var _ Transport = (*FakeDeck)(nil)
+
+// NewFakeDeck creates a FakeDeck emulating the given device type
+// (see constants for supported types)
+func NewFakeDeck(devicePID uint16) (*FakeDeck, error) {
+	createCfg, ok := decks[devicePID]
+	if !ok {
+		return nil, fmt.Errorf("unsupported device 0x%04x", devicePID)
+	}
+
+	proto, ok := fakeProtocols[devicePID]
+	if !ok {
+		return nil, fmt.Errorf("no fake protocol for device 0x%04x", devicePID)
+	}
+
+	cfg := createCfg()
+
+	var (
+		dialStates     []byte
+		lcd            *image.RGBA
+		touchKeyColors []color.RGBA
+	)
+
+	if dials, ok := cfg.(dialDeckConfig); ok {
+		dialStates = make([]byte, dials.NumDials())
+	}
+
+	if lcdCfg, ok := cfg.(lcdDeckConfig); ok {
+		lcd = image.NewRGBA(image.Rectangle{Max: lcdCfg.LCDSize()})
+	}
+
+	if barCfg, ok := cfg.(infoBarDeckConfig); ok {
+		lcd = image.NewRGBA(image.Rectangle{Max: barCfg.InfoBarSize()})
+	}
+
+	touchKeyColors = make([]color.RGBA, numTouchKeys(cfg))
+
+	return &FakeDeck{
+		cfg:   cfg,
+		proto: proto,
+
+		brightness:   -1,
+		dialStates:   dialStates,
+		firmware:     fakeDeckDefaultFirmware,
+		imageBuffers: make(map[int]*bytes.Buffer),
+		images:       make(map[int]image.Image),
+		keyStates:    make([]byte, cfg.KeyDataOffset()+cfg.NumKeys()+len(touchKeyColors)),
+		lcd:          lcd,
+		lcdBuffer:    new(bytes.Buffer),
+		serial:       fakeDeckDefaultSerial,
+
+		touchKeyColors: touchKeyColors,
+
+		gone:    make(chan struct{}),
+		reports: make(chan []byte, fakeDeckReportBuffer),
+	}, nil
+}
+
+// Brightness returns the last brightness set on the device or -1 if
+// the brightness was never set
+func (f *FakeDeck) Brightness() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.brightness
+}
+
+// Close marks the device as closed, further reads and writes will fail
+func (f *FakeDeck) Close() error {
+	f.Unplug()
+	return nil
+}
+
+// FeatureReports returns a copy of all feature reports sent to the device
+func (f *FakeDeck) FeatureReports() [][]byte {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.copyPackets(f.featureReports)
+}
+
+// GetFeatureReport answers the firmware feature report of the device
+func (f *FakeDeck) GetFeatureReport(p []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return 0, fmt.Errorf("fake deck closed")
+	}
+
+	if len(p) == 0 || p[0] != f.proto.firmwareReportID {
+		return 0, fmt.Errorf("unsupported feature report")
+	}
+
+	copy(p[f.proto.firmwareOffset:], f.firmware)
+	return len(p), nil
+}
+
+// GetSerialNbr returns the serial of the device
+func (f *FakeDeck) GetSerialNbr() (string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.serial, nil
+}
+
+// InfoBarImage returns a copy of the current content of the info bar
+// or nil if the device has no info bar
+func (f *FakeDeck) InfoBarImage() image.Image {
+	if _, ok := f.cfg.(infoBarDeckConfig); !ok {
+		return nil
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return imaging.Clone(f.lcd)
+}
+
+// KeyImage returns the last image decoded for the given key or nil if
+// no image was written to the key
+func (f *FakeDeck) KeyImage(keyIdx int) image.Image {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.images[keyIdx]
+}
+
+// KeyImages returns a copy of all images decoded per key
+func (f *FakeDeck) KeyImages() map[int]image.Image {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return maps.Clone(f.images)
+}
+
+// LCDImage returns a copy of the current content of the LCD strip or
+// nil if the device has no LCD strip
+func (f *FakeDeck) LCDImage() image.Image {
+	if _, ok := f.cfg.(lcdDeckConfig); !ok {
+		return nil
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return imaging.Clone(f.lcd)
+}
+
+// LogoShown reports whether the device was reset to the logo after the
+// last image was written
+func (f *FakeDeck) LogoShown() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.logoShown
+}
+
+// Press injects a key-down event for the given key, touch keys are
+// addressed by the indices following the regular keys
+func (f *FakeDeck) Press(keyIdx int) error { return f.setKeyState(keyIdx, EventTypeDown) }
+
+// PressDial injects a press event for the given dial
+func (f *FakeDeck) PressDial(dial int) error { return f.setDialState(dial, 1) }
+
+// Read blocks until an injected key event is available and copies the
+// resulting input report into p. After the device was closed or
+// unplugged an error is returned.
+func (f *FakeDeck) Read(p []byte) (int, error) {
+	select {
+	case report := <-f.reports:
+		return copy(p, report), nil
+	case <-f.gone:
+		return -1, fmt.Errorf("fake deck closed")
+	}
+}
+
+// ReadWithTimeout works like Read but returns hid.ErrTimeout when no
+// report was injected within the timeout
+func (f *FakeDeck) ReadWithTimeout(p []byte, timeout time.Duration) (int, error) {
+	select {
+	case report := <-f.reports:
+		return copy(p, report), nil
+	case <-f.gone:
+		return -1, fmt.Errorf("fake deck closed")
+	case <-time.After(timeout):
+		return 0, hid.ErrTimeout
+	}
+}
+
+// Release injects a key-up event for the given key
+func (f *FakeDeck) Release(keyIdx int) error { return f.setKeyState(keyIdx, EventTypeUp) }
+
+// ReleaseDial injects a release event for the given dial
+func (f *FakeDeck) ReleaseDial(dial int) error { return f.setDialState(dial, 0) }
+
+// RotateDial injects a rotation of the given dial by delta ticks,
+// negative values rotate counter-clockwise
+func (f *FakeDeck) RotateDial(dial, delta int) error {
+	if dial < 0 || dial >= len(f.dialStates) {
+		return ErrNotSupported
+	}
+
+	report := make([]byte, 9)
+	report[0] = 0x01
+	report[1] = 0x03
+	report[2] = 0x05
+	report[4] = 0x01
+	report[5+dial] = byte(int8(delta)) //#nosec:G115 // test helper, caller must pass sane values
+
+	f.reports <- report
+	return nil
+}
+
+// SendFeatureReport records the report and applies brightness and
+// logo-reset reports to the device state
+func (f *FakeDeck) SendFeatureReport(p []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return 0, fmt.Errorf("fake deck closed")
+	}
+
+	f.featureReports = append(f.featureReports, slices.Clone(p))
+
+	switch {
+	case len(f.proto.brightnessPrefix) > 0 && bytes.HasPrefix(p, f.proto.brightnessPrefix) && len(p) > len(f.proto.brightnessPrefix):
+		f.brightness = int(p[len(f.proto.brightnessPrefix)])
+
+	case len(f.proto.resetPrefix) > 0 && bytes.HasPrefix(p, f.proto.resetPrefix):
+		f.logoShown = true
+
+	case len(f.touchKeyColors) > 0 && len(p) >= 6 && p[0] == 0x03 && p[1] == 0x06:
+		if idx := int(p[2]) - f.cfg.NumKeys(); idx >= 0 && idx < len(f.touchKeyColors) {
+			f.touchKeyColors[idx] = color.RGBA{p[3], p[4], p[5], 0xff}
+		}
+	}
+
+	return len(p), nil
+}
+
+// SetFirmware sets the firmware version reported by the device
+func (f *FakeDeck) SetFirmware(firmware string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.firmware = firmware
+}
+
+// SetSerial sets the serial reported by the device
+func (f *FakeDeck) SetSerial(serial string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.serial = serial
+}
+
+// TouchKeyColor returns the LED color of the given touch key (0-based,
+// not including the regular keys)
+func (f *FakeDeck) TouchKeyColor(touchKeyIdx int) color.RGBA {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if touchKeyIdx < 0 || touchKeyIdx >= len(f.touchKeyColors) {
+		return color.RGBA{}
+	}
+
+	return f.touchKeyColors[touchKeyIdx]
+}
+
+// Touch injects a touch event (EventTypeTouchTap, EventTypeTouchLongPress
+// or EventTypeTouchSwipe) on the touch strip, end is only used for swipes
+func (f *FakeDeck) Touch(evtType EventType, point, end image.Point) error {
+	if _, ok := f.cfg.(lcdDeckConfig); !ok {
+		return ErrNotSupported
+	}
+
+	report := make([]byte, 14)
+	report[0] = 0x01
+	report[1] = 0x02
+	report[2] = 0x0e
+
+	switch evtType {
+	case EventTypeTouchTap:
+		report[4] = 0x01
+	case EventTypeTouchLongPress:
+		report[4] = 0x02
+	case EventTypeTouchSwipe:
+		report[4] = 0x03
+	default:
+		return fmt.Errorf("event type %d is no touch event", evtType)
+	}
+
+	for i, v := range []int{point.X, point.Y, end.X, end.Y} {
+		binary.LittleEndian.PutUint16(report[6+2*i:], uint16(v)) //#nosec:G115 // test helper, caller must pass sane values
+	}
+
+	f.reports <- report
+	return nil
+}
+
+// Write records the packet and decodes image packets into key images
+func (f *FakeDeck) Write(p []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return 0, fmt.Errorf("fake deck closed")
+	}
+
+	f.writes = append(f.writes, slices.Clone(p))
+
+	if len(p) < f.proto.imageHeaderSize || p[0] != 0x02 {
+		return len(p), nil
+	}
+
+	handle := f.handleImagePacket
+	if f.proto.lcdCommand != 0 && p[1] == f.proto.lcdCommand {
+		handle = f.handleLCDPacket
+	}
+
+	if err := handle(p); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Unplug simulates the removal of the device: pending and further
+// reads fail as do all writes
+func (f *FakeDeck) Unplug() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.closed = true
+	f.goneOnce.Do(func() { close(f.gone) })
+}
+
+// Writes returns a copy of all packets written to the device
+func (f *FakeDeck) Writes() [][]byte {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.copyPackets(f.writes)
+}
+
+func (*FakeDeck) copyPackets(packets [][]byte) [][]byte {
+	out := make([][]byte, 0, len(packets))
+	for _, p := range packets {
+		out = append(out, slices.Clone(p))
+	}
+
+	return out
+}
+
+func (f *FakeDeck) handleImagePacket(p []byte) error {
+	header := p[:f.proto.imageHeaderSize]
+
+	rawIdx, last := f.proto.parseImageHeader(header)
+	if rawIdx < 0 || rawIdx >= f.cfg.NumKeys() {
+		return fmt.Errorf("key index %d out of bounds", rawIdx)
+	}
+
+	keyIdx := f.cfg.TransformKeyIndex(rawIdx)
+
+	buf, ok := f.imageBuffers[keyIdx]
+	if !ok {
+		buf = new(bytes.Buffer)
+		f.imageBuffers[keyIdx] = buf
+	}
+
+	payload := p[f.proto.imageHeaderSize:]
+	length := f.proto.payloadLength(header, payload, buf.Bytes())
+	if length < 0 || length > len(payload) {
+		return fmt.Errorf("payload length %d exceeds packet", length)
+	}
+	buf.Write(payload[:length])
+
+	if !last {
+		return nil
+	}
+
+	delete(f.imageBuffers, keyIdx)
+
+	img, err := f.proto.decodeImage(buf)
+	if err != nil {
+		return fmt.Errorf("decoding image for key %d: %w", keyIdx, err)
+	}
+
+	f.images[keyIdx] = f.proto.restoreImage(img)
+	f.logoShown = false
+
+	return nil
+}
+
+func (f *FakeDeck) handleLCDPacket(p []byte) error {
+	header := p[:f.proto.lcdHeaderSize]
+
+	rect, last, length := f.proto.parseLCDHeader(header)
+	if !rect.In(f.lcd.Bounds()) {
+		return fmt.Errorf("region %s out of display bounds", rect)
+	}
+
+	payload := p[f.proto.lcdHeaderSize:]
+	if length < 0 || length > len(payload) {
+		return fmt.Errorf("payload length %d exceeds packet", length)
+	}
+	f.lcdBuffer.Write(payload[:length])
+
+	if !last {
+		return nil
+	}
+
+	defer f.lcdBuffer.Reset()
+
+	img, err := f.proto.decodeImage(f.lcdBuffer)
+	if err != nil {
+		return fmt.Errorf("decoding display image: %w", err)
+	}
+
+	img = f.proto.restoreLCD(img)
+	draw.Draw(f.lcd, rect, img, img.Bounds().Min, draw.Src)
+	f.logoShown = false
+
+	return nil
+}
+
+func (f *FakeDeck) setDialState(dial int, state byte) error {
+	if dial < 0 || dial >= len(f.dialStates) {
+		return ErrNotSupported
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.dialStates[dial] = state
+
+	report := make([]byte, 5+len(f.dialStates))
+	report[0] = 0x01
+	report[1] = 0x03
+	report[2] = 0x05
+	copy(report[5:], f.dialStates)
+
+	f.reports <- report
+	return nil
+}
+
+func (f *FakeDeck) setKeyState(keyIdx int, state EventType) error {
+	if keyIdx < 0 || keyIdx >= f.cfg.NumKeys()+len(f.touchKeyColors) {
+		return fmt.Errorf("key index %d out of bounds", keyIdx)
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.keyStates[f.cfg.KeyDataOffset()+f.cfg.TransformKeyIndex(keyIdx)] = byte(state)
+	f.reports <- slices.Clone(f.keyStates)
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package streamdeck
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEventTimeout = time.Second
+
+func newFakeClient(t *testing.T, model uint16) (*Client, *FakeDeck) {
+	t.Helper()
+
+	fake, err := NewFakeDeck(model)
+	require.NoError(t, err)
+
+	client, err := NewWithTransport(model, fake)
+	require.NoError(t, err)
+
+	return client, fake
+}
+
+// withoutTiming removes the timing information from events to be
+// compared against expected events
+func withoutTiming(evts ...Event) []Event {
+	out := make([]Event, 0, len(evts))
+	for _, evt := range evts {
+		evt.Time, evt.Duration = time.Time{}, 0
+		out = append(out, evt)
+	}
+
+	return out
+}
+
+func assertColorNear(t *testing.T, expected color.RGBA, actual color.Color) {
+	t.Helper()
+
+	const tolerance = 8
+
+	r, g, b, a := actual.RGBA()
+	for i, v := range [][2]uint32{
+		{uint32(expected.R), r >> 8},
+		{uint32(expected.G), g >> 8},
+		{uint32(expected.B), b >> 8},
+		{uint32(expected.A), a >> 8},
+	} {
+		assert.InDelta(t, v[0], v[1], tolerance, "color component %d", i)
+	}
+}
+
+func TestFakeDeckDecodesImages(t *testing.T) {
+	t.Parallel()
+
+	for model := range DeckToName {
+		t.Run(DeckToName[model], func(t *testing.T) {
+			t.Parallel()
+
+			client, fake := newFakeClient(t, model)
+			if !client.HasDisplay() {
+				t.Skip("device has no display")
+			}
+
+			// Left half red, right half blue to detect rotated images
+			img := image.NewRGBA(image.Rect(0, 0, client.IconSize(), client.IconSize()))
+			for x := 0; x < client.IconSize(); x++ {
+				for y := 0; y < client.IconSize(); y++ {
+					if x < client.IconSize()/2 {
+						img.Set(x, y, color.RGBA{0xff, 0x0, 0x0, 0xff})
+					} else {
+						img.Set(x, y, color.RGBA{0x0, 0x0, 0xff, 0xff})
+					}
+				}
+			}
+
+			require.NoError(t, client.FillImage(1, img))
+			require.NoError(t, client.FillColor(2, color.RGBA{0x0, 0xff, 0x0, 0xff}))
+
+			got := fake.KeyImage(1)
+			require.NotNil(t, got)
+			assert.Equal(t, img.Bounds().Size(), got.Bounds().Size())
+			assertColorNear(t, color.RGBA{0xff, 0x0, 0x0, 0xff}, got.At(got.Bounds().Min.X+4, got.Bounds().Min.Y+4))
+			assertColorNear(t, color.RGBA{0x0, 0x0, 0xff, 0xff}, got.At(got.Bounds().Max.X-4, got.Bounds().Max.Y-4))
+
+			got = fake.KeyImage(2)
+			require.NotNil(t, got)
+			assertColorNear(t, color.RGBA{0x0, 0xff, 0x0, 0xff}, got.At(got.Bounds().Min.X+client.IconSize()/2, got.Bounds().Min.Y+client.IconSize()/2))
+
+			assert.Nil(t, fake.KeyImage(0))
+		})
+	}
+}
+
+func TestFakeDeckDeviceInfo(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newFakeClient(t, StreamDeckMini)
+	fake.SetSerial("AL12345678")
+	fake.SetFirmware("3.00.001")
+
+	serial, err := client.Serial()
+	require.NoError(t, err)
+	assert.Equal(t, "AL12345678", serial)
+
+	firmware, err := client.GetFimwareVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "3.00.001", firmware)
+
+	require.NoError(t, client.SetBrightness(42))
+	assert.Equal(t, 42, fake.Brightness())
+
+	require.NoError(t, client.ClearKey(0))
+	assert.False(t, fake.LogoShown())
+
+	require.NoError(t, client.ResetToLogo())
+	assert.True(t, fake.LogoShown())
+	assert.Len(t, fake.FeatureReports(), 2)
+
+	require.NoError(t, client.Close())
+	assert.Error(t, client.ClearKey(0))
+}
+
+func TestFakeDeckKeyEvents(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newFakeClient(t, StreamDeckXL)
+	evts := client.Subscribe(t.Context())
+
+	require.NoError(t, fake.Press(7))
+	require.NoError(t, fake.Release(7))
+	require.Error(t, fake.Press(32))
+
+	for _, expected := range []Event{
+		{Key: 7, Type: EventTypeDown},
+		{Key: 7, Type: EventTypeUp},
+	} {
+		select {
+		case evt := <-evts:
+			assert.Equal(t, expected, withoutTiming(evt)[0])
+		case <-time.After(testEventTimeout):
+			t.Fatal("timeout waiting for event")
+		}
+	}
+}
+
+func TestDeviceLoss(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newFakeClient(t, StreamDeckMK2)
+	evts := client.Subscribe(t.Context())
+
+	require.NoError(t, client.Err())
+	require.NoError(t, fake.Press(1))
+
+	select {
+	case evt := <-evts:
+		assert.Equal(t, Event{Key: 1, Type: EventTypeDown}, withoutTiming(evt)[0])
+	case <-time.After(testEventTimeout):
+		t.Fatal("timeout waiting for event")
+	}
+
+	fake.Unplug()
+
+	select {
+	case <-client.Done():
+	case <-time.After(testEventTimeout):
+		t.Fatal("timeout waiting for device loss")
+	}
+
+	require.Error(t, client.Err())
+	require.Error(t, client.FillColor(0, color.RGBA{0xff, 0x0, 0x0, 0xff}))
+
+	_, ok := <-evts
+	assert.False(t, ok, "event channel should be closed")
+}
+
+func TestEventTiming(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newFakeClient(t, StreamDeckPlus)
+	evts := client.Subscribe(t.Context())
+
+	start := time.Now()
+	require.NoError(t, fake.Press(2))
+	require.NoError(t, fake.PressDial(1))
+
+	// Hold time is measured from reading the down events
+	got := collectEvents(t, evts, 2)
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, fake.Release(2))
+	require.NoError(t, fake.ReleaseDial(1))
+
+	got = append(got, collectEvents(t, evts, 2)...)
+
+	for _, evt := range got {
+		assert.False(t, evt.Time.Before(start), "event time before injection")
+	}
+
+	assert.Zero(t, got[0].Duration)
+	assert.GreaterOrEqual(t, got[2].Duration, 20*time.Millisecond)
+	assert.Equal(t, got[2].Time.Sub(got[0].Time), got[2].Duration)
+	assert.GreaterOrEqual(t, got[3].Duration, 20*time.Millisecond)
+}
+
+func TestHeldKeys(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newFakeClient(t, StreamDeckXL)
+	evts := client.Subscribe(t.Context())
+
+	assert.Empty(t, client.HeldKeys())
+
+	require.NoError(t, fake.Press(7))
+	require.NoError(t, fake.Press(0))
+	collectEvents(t, evts, 2)
+	assert.Equal(t, []int{0, 7}, client.HeldKeys())
+
+	require.NoError(t, fake.Release(7))
+	collectEvents(t, evts, 1)
+	assert.Equal(t, []int{0}, client.HeldKeys())
+}
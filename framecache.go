@@ -0,0 +1,107 @@
+package streamdeck
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// FrameStats contains the number of key images sent to the device
+	// and the number of images skipped as the key already showed them
+	FrameStats struct {
+		Sent    uint64
+		Skipped uint64
+	}
+
+	// frameCache remembers a hash of the content of every key to skip
+	// writing identical content again
+	frameCache struct {
+		lock   sync.Mutex
+		hashes map[int]uint64
+
+		sent    atomic.Uint64
+		skipped atomic.Uint64
+	}
+)
+
+func newFrameCache() *frameCache {
+	return &frameCache{hashes: make(map[int]uint64)}
+}
+
+// write calls fn to write the content with the given hash to the key
+// unless the key already shows that content
+func (f *frameCache) write(keyIdx int, hash uint64, fn func() error) error {
+	f.lock.Lock()
+	current, ok := f.hashes[keyIdx]
+	f.lock.Unlock()
+
+	if ok && current == hash {
+		f.skipped.Add(1)
+		return nil
+	}
+
+	if err := fn(); err != nil {
+		// We don't know what the key shows now
+		f.invalidate(keyIdx)
+		return err
+	}
+
+	f.lock.Lock()
+	f.hashes[keyIdx] = hash
+	f.lock.Unlock()
+
+	f.sent.Add(1)
+	return nil
+}
+
+// invalidate removes the given keys from the cache, all keys if none
+// are given
+func (f *frameCache) invalidate(keys ...int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if len(keys) == 0 {
+		f.hashes = make(map[int]uint64)
+		return
+	}
+
+	for _, k := range keys {
+		delete(f.hashes, k)
+	}
+}
+
+func (f *frameCache) stats() FrameStats {
+	return FrameStats{Sent: f.sent.Load(), Skipped: f.skipped.Load()}
+}
+
+func hashColor(col color.RGBA) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte{'c', col.R, col.G, col.B, col.A})
+
+	return h.Sum64()
+}
+
+func hashImage(img image.Image) uint64 {
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(image.Rectangle{Max: img.Bounds().Size()})
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	}
+
+	h := fnv.New64a()
+
+	size := rgba.Rect.Size()
+	_ = binary.Write(h, binary.LittleEndian, [2]int32{int32(size.X), int32(size.Y)}) //#nosec:G115 // key images are small
+
+	for y := rgba.Rect.Min.Y; y < rgba.Rect.Max.Y; y++ {
+		start := rgba.PixOffset(rgba.Rect.Min.X, y)
+		_, _ = h.Write(rgba.Pix[start : start+size.X*4])
+	}
+
+	return h.Sum64()
+}
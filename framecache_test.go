@@ -0,0 +1,63 @@
+package streamdeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameCacheSkipsIdenticalWrites(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newFakeClient(t, StreamDeckMini)
+
+	red := color.RGBA{0xff, 0x0, 0x0, 0xff}
+	img := image.NewRGBA(image.Rect(0, 0, client.IconSize(), client.IconSize()))
+	draw.Draw(img, img.Bounds(), image.NewUniform(red), image.Point{}, draw.Src)
+
+	require.NoError(t, client.FillImage(0, img))
+	writes := len(fake.Writes())
+
+	// Same content as image and as color must not be sent again
+	require.NoError(t, client.FillImage(0, img))
+	require.NoError(t, client.FillColor(0, red))
+	assert.Len(t, fake.Writes(), writes)
+	assert.Equal(t, FrameStats{Sent: 1, Skipped: 2}, client.FrameStats())
+
+	// Other keys and other content are sent
+	require.NoError(t, client.FillColor(1, red))
+	require.NoError(t, client.ClearKey(0))
+	assert.Equal(t, FrameStats{Sent: 3, Skipped: 2}, client.FrameStats())
+	assertColorNear(t, color.RGBA{0x0, 0x0, 0x0, 0xff}, fake.KeyImage(0).At(0, 0))
+
+	// Resetting the device content invalidates the cache
+	require.NoError(t, client.ResetToLogo())
+	require.NoError(t, client.ClearKey(0))
+	assert.Equal(t, FrameStats{Sent: 4, Skipped: 2}, client.FrameStats())
+
+	client.InvalidateFrameCache()
+	require.NoError(t, client.ClearKey(0))
+	assert.Equal(t, FrameStats{Sent: 5, Skipped: 2}, client.FrameStats())
+}
+
+func TestFrameCacheClearAllKeys(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newFakeClient(t, StreamDeckNeo)
+	total := uint64(client.NumKeys() + client.NumTouchKeys()) //#nosec:G115 // small positive number
+
+	require.NoError(t, client.ClearAllKeys())
+	assert.Equal(t, FrameStats{Sent: total}, client.FrameStats())
+
+	require.NoError(t, client.ClearAllKeys())
+	assert.Equal(t, FrameStats{Sent: total, Skipped: total}, client.FrameStats())
+
+	// Touch keys are cached by their color
+	require.NoError(t, client.SetTouchKeyColor(0, color.RGBA{0x0, 0x0, 0xff, 0xff}))
+	require.NoError(t, client.FillColor(client.NumKeys(), color.RGBA{0x0, 0x0, 0xff, 0xff}))
+	assert.Equal(t, FrameStats{Sent: total + 1, Skipped: total + 1}, client.FrameStats())
+}
@@ -0,0 +1,229 @@
+package streamdeck
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	defaultGestureLongPress = 500 * time.Millisecond
+	gestureEventBuffer      = 100
+)
+
+type (
+	// GestureConfig contains the thresholds used by the GestureDetector
+	GestureConfig struct {
+		// LongPress is the time a key must be held down to emit a
+		// long-press (default 500ms)
+		LongPress time.Duration
+		// DoubleTap is the time window for a second tap to be detected
+		// as double-tap. Taps are delayed by this window, zero disables
+		// double-tap detection and emits taps immediately.
+		DoubleTap time.Duration
+		// RepeatDelay is the time a key must be held down to start
+		// emitting hold-repeat events, zero disables hold-repeat
+		RepeatDelay time.Duration
+		// RepeatInterval is the time between two hold-repeat events
+		// (defaults to RepeatDelay)
+		RepeatInterval time.Duration
+	}
+
+	// GestureDetector converts key up / down events into gestures:
+	// EventTypeTap, EventTypeLongPress, EventTypeDoubleTap and
+	// EventTypeHoldRepeat. All other events are passed through.
+	GestureDetector struct {
+		cfg GestureConfig
+	}
+
+	gestureKeyState struct {
+		down       bool
+		downAt     time.Time
+		longSent   bool
+		nextRepeat time.Time
+
+		// pendingTap contains the release time of a tap waiting for
+		// the double-tap window to pass
+		pendingTap time.Time
+		tapHeld    time.Duration
+	}
+)
+
+// NewGestureDetector creates a GestureDetector using the given
+// thresholds, missing values are set to their defaults
+func NewGestureDetector(cfg GestureConfig) *GestureDetector {
+	if cfg.LongPress <= 0 {
+		cfg.LongPress = defaultGestureLongPress
+	}
+
+	if cfg.RepeatInterval <= 0 {
+		cfg.RepeatInterval = cfg.RepeatDelay
+	}
+
+	return &GestureDetector{cfg: cfg}
+}
+
+// Detect reads events from the given channel and returns a channel
+// emitting the detected gestures. The returned channel is closed when
+// the context is cancelled or the input channel is closed.
+func (g *GestureDetector) Detect(ctx context.Context, in <-chan Event) <-chan Event {
+	out := make(chan Event, gestureEventBuffer)
+
+	go func() {
+		defer close(out)
+
+		var (
+			keys  = make(map[int]*gestureKeyState)
+			timer = time.NewTimer(0)
+		)
+		defer timer.Stop()
+		<-timer.C
+
+		emit := func(evt Event) bool {
+			select {
+			case out <- evt:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			var timerC <-chan time.Time
+			if next := g.nextDeadline(keys); !next.IsZero() {
+				timer.Reset(time.Until(next))
+				timerC = timer.C
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+
+			case evt, ok := <-in:
+				if !ok {
+					return
+				}
+
+				for _, gesture := range g.handleEvent(keys, evt) {
+					if !emit(gesture) {
+						return
+					}
+				}
+
+			case now := <-timerC:
+				for _, gesture := range g.handleDeadlines(keys, now) {
+					if !emit(gesture) {
+						return
+					}
+				}
+			}
+
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+func (g *GestureDetector) handleDeadlines(keys map[int]*gestureKeyState, now time.Time) (out []Event) {
+	for key, state := range keys {
+		if state.down && !state.longSent && !now.Before(state.downAt.Add(g.cfg.LongPress)) {
+			state.longSent = true
+			out = append(out, Event{Key: key, Type: EventTypeLongPress, Time: now, Duration: now.Sub(state.downAt)})
+		}
+
+		if state.down && g.cfg.RepeatDelay > 0 && !now.Before(state.nextRepeat) {
+			state.nextRepeat = state.nextRepeat.Add(g.cfg.RepeatInterval)
+			out = append(out, Event{Key: key, Type: EventTypeHoldRepeat, Time: now, Duration: now.Sub(state.downAt)})
+		}
+
+		if !state.pendingTap.IsZero() && !now.Before(state.pendingTap.Add(g.cfg.DoubleTap)) {
+			out = append(out, Event{Key: key, Type: EventTypeTap, Time: now, Duration: state.tapHeld})
+			state.pendingTap = time.Time{}
+		}
+	}
+
+	return out
+}
+
+func (g *GestureDetector) handleEvent(keys map[int]*gestureKeyState, evt Event) []Event {
+	if evt.Type != EventTypeDown && evt.Type != EventTypeUp {
+		return []Event{evt}
+	}
+
+	state, ok := keys[evt.Key]
+	if !ok {
+		state = &gestureKeyState{}
+		keys[evt.Key] = state
+	}
+
+	at := evt.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	if evt.Type == EventTypeDown {
+		state.down = true
+		state.downAt = at
+		state.longSent = false
+		state.nextRepeat = at.Add(g.cfg.RepeatDelay)
+		return nil
+	}
+
+	if !state.down {
+		return nil
+	}
+
+	state.down = false
+	held := at.Sub(state.downAt)
+
+	if state.longSent || held >= g.cfg.LongPress {
+		if !state.longSent {
+			// Deadline was not handled before the release was read
+			return []Event{{Key: evt.Key, Type: EventTypeLongPress, Time: at, Duration: held}}
+		}
+		return nil
+	}
+
+	switch {
+	case g.cfg.DoubleTap <= 0:
+		return []Event{{Key: evt.Key, Type: EventTypeTap, Time: at, Duration: held}}
+
+	case !state.pendingTap.IsZero():
+		state.pendingTap = time.Time{}
+		return []Event{{Key: evt.Key, Type: EventTypeDoubleTap, Time: at, Duration: held}}
+
+	default:
+		state.pendingTap = at
+		state.tapHeld = held
+		return nil
+	}
+}
+
+func (g *GestureDetector) nextDeadline(keys map[int]*gestureKeyState) (next time.Time) {
+	consider := func(t time.Time) {
+		if next.IsZero() || t.Before(next) {
+			next = t
+		}
+	}
+
+	for _, state := range keys {
+		if state.down && !state.longSent {
+			consider(state.downAt.Add(g.cfg.LongPress))
+		}
+
+		if state.down && g.cfg.RepeatDelay > 0 {
+			consider(state.nextRepeat)
+		}
+
+		if !state.pendingTap.IsZero() {
+			consider(state.pendingTap.Add(g.cfg.DoubleTap))
+		}
+	}
+
+	return next
+}
@@ -0,0 +1,106 @@
+package streamdeck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGestureTapAndPassThrough(t *testing.T) {
+	t.Parallel()
+
+	in := make(chan Event, 10)
+	out := NewGestureDetector(GestureConfig{}).Detect(t.Context(), in)
+
+	now := time.Now()
+	in <- Event{Key: 2, Type: EventTypeDown, Time: now}
+	in <- Event{Key: 2, Type: EventTypeUp, Time: now.Add(80 * time.Millisecond)}
+	in <- Event{Type: EventTypeDialRotate, Dial: 1, Delta: 2}
+
+	evts := collectEvents(t, out, 2)
+	assert.Equal(t, Event{Key: 2, Type: EventTypeTap, Time: now.Add(80 * time.Millisecond), Duration: 80 * time.Millisecond}, evts[0])
+	assert.Equal(t, Event{Type: EventTypeDialRotate, Dial: 1, Delta: 2}, evts[1])
+
+	close(in)
+	waitClosed(t, out)
+}
+
+func TestGestureLongPress(t *testing.T) {
+	t.Parallel()
+
+	in := make(chan Event, 10)
+	out := NewGestureDetector(GestureConfig{LongPress: 50 * time.Millisecond}).Detect(t.Context(), in)
+
+	in <- Event{Key: 0, Type: EventTypeDown, Time: time.Now()}
+
+	// Long-press is emitted while the key is still held down
+	evt := collectEvents(t, out, 1)[0]
+	assert.Equal(t, EventTypeLongPress, evt.Type)
+	assert.GreaterOrEqual(t, evt.Duration, 50*time.Millisecond)
+
+	in <- Event{Key: 0, Type: EventTypeUp, Time: time.Now()}
+	in <- Event{Key: 0, Type: EventTypeDown, Time: time.Now()}
+	in <- Event{Key: 0, Type: EventTypeUp, Time: time.Now()}
+
+	// Release after the long-press does not emit a tap
+	assert.Equal(t, EventTypeTap, collectEvents(t, out, 1)[0].Type)
+}
+
+func TestGestureDoubleTap(t *testing.T) {
+	t.Parallel()
+
+	in := make(chan Event, 10)
+	out := NewGestureDetector(GestureConfig{DoubleTap: 100 * time.Millisecond}).Detect(t.Context(), in)
+
+	now := time.Now()
+	in <- Event{Key: 1, Type: EventTypeDown, Time: now}
+	in <- Event{Key: 1, Type: EventTypeUp, Time: now.Add(10 * time.Millisecond)}
+	in <- Event{Key: 1, Type: EventTypeDown, Time: now.Add(20 * time.Millisecond)}
+	in <- Event{Key: 1, Type: EventTypeUp, Time: now.Add(30 * time.Millisecond)}
+
+	evt := collectEvents(t, out, 1)[0]
+	assert.Equal(t, EventTypeDoubleTap, evt.Type)
+	assert.Equal(t, 1, evt.Key)
+
+	// Single tap is emitted after the double-tap window passed
+	start := time.Now()
+	in <- Event{Key: 1, Type: EventTypeDown, Time: start}
+	in <- Event{Key: 1, Type: EventTypeUp, Time: start.Add(5 * time.Millisecond)}
+
+	evt = collectEvents(t, out, 1)[0]
+	assert.Equal(t, EventTypeTap, evt.Type)
+	assert.Equal(t, 5*time.Millisecond, evt.Duration)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestGestureHoldRepeat(t *testing.T) {
+	t.Parallel()
+
+	in := make(chan Event, 10)
+	out := NewGestureDetector(GestureConfig{
+		LongPress:      time.Hour,
+		RepeatDelay:    40 * time.Millisecond,
+		RepeatInterval: 20 * time.Millisecond,
+	}).Detect(t.Context(), in)
+
+	in <- Event{Key: 3, Type: EventTypeDown, Time: time.Now()}
+
+	evts := collectEvents(t, out, 3)
+	for _, evt := range evts {
+		assert.Equal(t, EventTypeHoldRepeat, evt.Type)
+		assert.Equal(t, 3, evt.Key)
+	}
+	assert.GreaterOrEqual(t, evts[2].Duration, 80*time.Millisecond)
+
+	in <- Event{Key: 3, Type: EventTypeUp, Time: time.Now()}
+	close(in)
+
+	// Release ends the repeats and emits a tap as the long-press
+	// threshold was not reached
+	var last Event
+	for evt := range out {
+		last = evt
+	}
+	assert.Equal(t, EventTypeTap, last.Type)
+}
@@ -5,7 +5,13 @@ go 1.26.0
 require (
 	github.com/disintegration/imaging v1.6.2
 	github.com/sstallion/go-hid v0.15.0
+	github.com/stretchr/testify v1.11.1
 	golang.org/x/image v0.44.0
 )
 
-require golang.org/x/sys v0.26.0 // indirect
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.26.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
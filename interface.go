@@ -3,8 +3,6 @@ package streamdeck
 import (
 	"image"
 	"image/color"
-
-	"github.com/sstallion/go-hid"
 )
 
 const (
@@ -14,7 +12,7 @@ const (
 
 type (
 	deckConfig interface {
-		SetDevice(dev *hid.Device)
+		SetDevice(dev Transport)
 
 		NumKeys() int
 		KeyColumns() int
@@ -33,6 +31,11 @@ type (
 		FillImage(keyIdx int, img image.Image) error
 		FillPanel(img image.RGBA) error
 
+		// EncodeImage converts a key image into the payload sent to
+		// the device, WriteEncoded sends such payload to a key
+		EncodeImage(img image.Image) ([]byte, error)
+		WriteEncoded(keyIdx int, payload []byte) error
+
 		ClearKey(keyIdx int) error
 		ClearAllKeys() error
 
@@ -45,5 +48,39 @@ type (
 
 	deckConfigCreateFunc func() deckConfig
 
+	// dialDeckConfig is implemented by decks having rotary encoders
+	dialDeckConfig interface {
+		NumDials() int
+	}
+
+	// infoBarDeckConfig is implemented by decks having a non-touch
+	// info bar display besides the keys
+	infoBarDeckConfig interface {
+		InfoBarSize() image.Point
+		FillInfoBar(img image.Image) error
+	}
+
+	// inputReportDecoder is implemented by decks sending input reports
+	// for other controls than keys. It returns the decoded events and
+	// whether the report contains key states to be handled by the client.
+	inputReportDecoder interface {
+		DecodeInputReport(buf []byte) (evts []Event, isKeyReport bool)
+	}
+
+	// lcdDeckConfig is implemented by decks having an LCD strip
+	// besides the keys
+	lcdDeckConfig interface {
+		LCDSize() image.Point
+		FillLCDRegion(rect image.Rectangle, img image.Image) error
+	}
+
+	// touchKeyDeckConfig is implemented by decks having capacitive
+	// keys with a colored LED instead of a display. Their states are
+	// reported after the regular keys within the key report.
+	touchKeyDeckConfig interface {
+		NumTouchKeys() int
+		SetTouchKeyColor(touchKeyIdx int, col color.RGBA) error
+	}
+
 	keyDirection uint
 )
@@ -0,0 +1,119 @@
+package streamdeck
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultPayloadCacheSize is the number of encoded key images kept,
+// for the largest keys this uses about 20MB
+const defaultPayloadCacheSize = 1024
+
+// encodedPayloads is shared by all clients as decks of the same model
+// use the same payloads
+var encodedPayloads = newPayloadCache(defaultPayloadCacheSize)
+
+type (
+	// EncodedImage contains a key image encoded for a specific device
+	// model (see Client.Encode)
+	EncodedImage struct {
+		hash    uint64
+		model   uint16
+		payload []byte
+	}
+
+	// EncodeCacheStats contains the usage of the cache of encoded key
+	// images shared by all clients
+	EncodeCacheStats struct {
+		Entries int
+		Hits    uint64
+		Misses  uint64
+	}
+
+	payloadKey struct {
+		hash  uint64
+		model uint16
+	}
+
+	// payloadCache is a LRU cache of encoded key images
+	payloadCache struct {
+		lock    sync.Mutex
+		entries map[payloadKey]*list.Element
+		order   *list.List
+		size    int
+
+		hits   atomic.Uint64
+		misses atomic.Uint64
+	}
+)
+
+// SetEncodeCacheSize sets the number of encoded key images kept in the
+// cache shared by all clients, zero disables the cache
+func SetEncodeCacheSize(n int) { encodedPayloads.resize(max(n, 0)) }
+
+func newPayloadCache(size int) *payloadCache {
+	return &payloadCache{
+		entries: make(map[payloadKey]*list.Element),
+		order:   list.New(),
+		size:    size,
+	}
+}
+
+func (p *payloadCache) get(key payloadKey) ([]byte, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	elem, ok := p.entries[key]
+	if !ok {
+		p.misses.Add(1)
+		return nil, false
+	}
+
+	p.hits.Add(1)
+	p.order.MoveToFront(elem)
+	return elem.Value.(EncodedImage).payload, true
+}
+
+func (p *payloadCache) put(enc EncodedImage) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	key := payloadKey{hash: enc.hash, model: enc.model}
+	if elem, ok := p.entries[key]; ok {
+		p.order.MoveToFront(elem)
+		return
+	}
+
+	p.entries[key] = p.order.PushFront(enc)
+	p.evict()
+}
+
+func (p *payloadCache) resize(size int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.size = size
+	p.evict()
+}
+
+func (p *payloadCache) stats() EncodeCacheStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return EncodeCacheStats{
+		Entries: p.order.Len(),
+		Hits:    p.hits.Load(),
+		Misses:  p.misses.Load(),
+	}
+}
+
+// evict removes the least recently used entries exceeding the size,
+// the lock must be held by the caller
+func (p *payloadCache) evict() {
+	for p.order.Len() > p.size {
+		elem := p.order.Back()
+		enc := p.order.Remove(elem).(EncodedImage)
+		delete(p.entries, payloadKey{hash: enc.hash, model: enc.model})
+	}
+}
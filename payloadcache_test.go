@@ -0,0 +1,76 @@
+package streamdeck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadCacheLRU(t *testing.T) {
+	t.Parallel()
+
+	cache := newPayloadCache(2)
+
+	cache.put(EncodedImage{hash: 1, model: StreamDeckXL, payload: []byte{1}})
+	cache.put(EncodedImage{hash: 2, model: StreamDeckXL, payload: []byte{2}})
+
+	// Same hash for another model is a separate entry
+	_, ok := cache.get(payloadKey{hash: 1, model: StreamDeckMini})
+	assert.False(t, ok)
+
+	// Access marks the entry as recently used, so 2 is evicted
+	payload, ok := cache.get(payloadKey{hash: 1, model: StreamDeckXL})
+	require.True(t, ok)
+	assert.Equal(t, []byte{1}, payload)
+
+	cache.put(EncodedImage{hash: 3, model: StreamDeckXL, payload: []byte{3}})
+
+	_, ok = cache.get(payloadKey{hash: 2, model: StreamDeckXL})
+	assert.False(t, ok)
+
+	assert.Equal(t, EncodeCacheStats{Entries: 2, Hits: 1, Misses: 2}, cache.stats())
+
+	cache.resize(0)
+	assert.Equal(t, 0, cache.stats().Entries)
+}
+
+func TestFillEncoded(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newFakeClient(t, StreamDeckXL)
+	img := testKeyImage(client.IconSize())
+
+	enc, err := client.Encode(img)
+	require.NoError(t, err)
+
+	// Encoding the same image again uses the cached payload
+	again, err := client.Encode(img)
+	require.NoError(t, err)
+	assert.Same(t, &enc.payload[0], &again.payload[0])
+
+	require.NoError(t, client.FillImage(0, img))
+	require.NoError(t, client.FillEncoded(1, enc))
+
+	writes := fake.Writes()
+	require.Zero(t, len(writes)%2)
+
+	// Both ways send the same image data to their keys
+	half := len(writes) / 2
+	for i := range half {
+		assert.Equal(t, writes[i][4:], writes[half+i][4:])
+		assert.Equal(t, byte(0), writes[i][2])
+		assert.Equal(t, byte(1), writes[half+i][2])
+	}
+
+	// Written key is not written again
+	require.NoError(t, client.FillEncoded(1, enc))
+	assert.Len(t, fake.Writes(), len(writes))
+
+	mini, _ := newFakeClient(t, StreamDeckMini)
+	require.Error(t, mini.FillEncoded(0, enc))
+
+	pedal, _ := newFakeClient(t, StreamDeckPedal)
+	_, err = pedal.Encode(img)
+	require.ErrorIs(t, err, ErrNotSupported)
+}
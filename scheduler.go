@@ -0,0 +1,204 @@
+package streamdeck
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// writePriorityWindow defines how long after a key was pressed or
+// released its writes are preferred over writes to other keys
+const writePriorityWindow = 2 * time.Second
+
+type (
+	// WriteQueueStats contains metrics of the queue used to write key
+	// images to the device
+	WriteQueueStats struct {
+		// Depth is the number of frames currently waiting to be written
+		Depth int
+		// Processed is the number of frames taken from the queue
+		// (including those skipped as the key already showed them)
+		Processed uint64
+		// Coalesced is the number of frames replaced by a newer frame
+		// for the same key before being written
+		Coalesced uint64
+		// AvgLatency and MaxLatency describe the time between queueing
+		// a frame and finishing its write
+		AvgLatency time.Duration
+		MaxLatency time.Duration
+	}
+
+	writeJob struct {
+		fn     func() error
+		queued time.Time
+		result chan error
+		seq    uint64
+	}
+
+	// writeScheduler serializes key writes in a single goroutine: only
+	// the latest pending frame per key is written and keys with recent
+	// user interaction are written first
+	writeScheduler struct {
+		lock        sync.Mutex
+		closed      bool
+		interaction map[int]time.Time
+		pending     map[int]*writeJob
+		seq         uint64
+
+		stopped chan struct{}
+		wake    chan struct{}
+
+		coalesced  atomic.Uint64
+		latencyMax atomic.Int64
+		latencySum atomic.Int64
+		processed  atomic.Uint64
+	}
+)
+
+func newWriteScheduler() *writeScheduler {
+	return &writeScheduler{
+		interaction: make(map[int]time.Time),
+		pending:     make(map[int]*writeJob),
+		stopped:     make(chan struct{}),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// interact marks the key as recently used so its writes are preferred
+func (s *writeScheduler) interact(keyIdx int, at time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.interaction[keyIdx] = at
+}
+
+// run writes the queued frames until done is closed, pending frames
+// are then discarded with ErrClosed
+func (s *writeScheduler) run(done <-chan struct{}) {
+	defer close(s.stopped)
+
+	for {
+		select {
+		case <-done:
+			s.shutdown()
+			return
+		default:
+		}
+
+		job := s.next(time.Now())
+		if job == nil {
+			select {
+			case <-s.wake:
+			case <-done:
+				s.shutdown()
+				return
+			}
+			continue
+		}
+
+		err := job.fn()
+
+		latency := int64(time.Since(job.queued))
+		s.latencySum.Add(latency)
+		for {
+			current := s.latencyMax.Load()
+			if latency <= current || s.latencyMax.CompareAndSwap(current, latency) {
+				break
+			}
+		}
+		s.processed.Add(1)
+
+		job.result <- err
+	}
+}
+
+// next removes the job to be written next from the queue: jobs for
+// keys with recent interaction first, all others in queue order
+func (s *writeScheduler) next(now time.Time) *writeJob {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var (
+		bestKey      = -1
+		bestPriority bool
+		best         *writeJob
+	)
+
+	for key, job := range s.pending {
+		priority := now.Sub(s.interaction[key]) < writePriorityWindow
+
+		switch {
+		case best == nil,
+			priority && !bestPriority,
+			priority == bestPriority && job.seq < best.seq:
+			bestKey, bestPriority, best = key, priority, job
+		}
+	}
+
+	if best != nil {
+		delete(s.pending, bestKey)
+	}
+
+	return best
+}
+
+func (s *writeScheduler) shutdown() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.closed = true
+	for key, job := range s.pending {
+		job.result <- ErrClosed
+		delete(s.pending, key)
+	}
+}
+
+func (s *writeScheduler) stats() WriteQueueStats {
+	s.lock.Lock()
+	depth := len(s.pending)
+	s.lock.Unlock()
+
+	stats := WriteQueueStats{
+		Depth:      depth,
+		Processed:  s.processed.Load(),
+		Coalesced:  s.coalesced.Load(),
+		MaxLatency: time.Duration(s.latencyMax.Load()),
+	}
+
+	if stats.Processed > 0 {
+		stats.AvgLatency = time.Duration(s.latencySum.Load() / int64(stats.Processed)) //#nosec:G115 // counter will not overflow int64
+	}
+
+	return stats
+}
+
+// submit queues fn to write a frame to the key and waits until it was
+// written. If the frame is replaced by a newer one for the same key
+// before being written submit returns nil without calling fn.
+func (s *writeScheduler) submit(keyIdx int, fn func() error) error {
+	job := &writeJob{fn: fn, queued: time.Now(), result: make(chan error, 1)}
+
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return ErrClosed
+	}
+
+	if old, ok := s.pending[keyIdx]; ok {
+		// Latest frame wins, the old one will never be shown
+		old.result <- nil
+		s.coalesced.Add(1)
+	}
+
+	s.seq++
+	job.seq = s.seq
+	s.pending[keyIdx] = job
+	s.lock.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return <-job.result
+}
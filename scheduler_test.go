@@ -0,0 +1,92 @@
+package streamdeck
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSchedulerOrder(t *testing.T) {
+	t.Parallel()
+
+	var (
+		done    = make(chan struct{})
+		gate    = make(chan struct{})
+		started = make(chan struct{})
+		order   []int
+		orderMu sync.Mutex
+		sched   = newWriteScheduler()
+		wg      sync.WaitGroup
+	)
+	defer close(done)
+	go sched.run(done)
+
+	write := func(key int) func() error {
+		return func() error {
+			orderMu.Lock()
+			defer orderMu.Unlock()
+			order = append(order, key)
+			return nil
+		}
+	}
+
+	submit := func(key int, fn func() error, queued func(WriteQueueStats) bool) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, sched.submit(key, fn))
+		}()
+
+		require.Eventually(t, func() bool { return queued(sched.stats()) }, time.Second, time.Millisecond)
+	}
+	depth := func(n int) func(WriteQueueStats) bool {
+		return func(s WriteQueueStats) bool { return s.Depth == n }
+	}
+
+	// Block the writer with the first frame to build up a queue
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, sched.submit(0, func() error {
+			close(started)
+			<-gate
+			return write(0)()
+		}))
+	}()
+	<-started
+
+	submit(1, write(1), depth(1))
+	submit(2, write(2), depth(2))
+	submit(3, write(3), depth(3))
+
+	// Newer frame for key 1 replaces the queued one
+	submit(1, write(10), func(s WriteQueueStats) bool { return s.Coalesced == 1 })
+
+	// Key 3 was pressed and must be written before the older frames
+	sched.interact(3, time.Now())
+
+	close(gate)
+	wg.Wait()
+
+	assert.Equal(t, []int{0, 3, 2, 10}, order)
+
+	stats := sched.stats()
+	assert.Equal(t, 0, stats.Depth)
+	assert.Equal(t, uint64(4), stats.Processed)
+	assert.Equal(t, uint64(1), stats.Coalesced)
+	assert.Positive(t, stats.MaxLatency)
+	assert.LessOrEqual(t, stats.AvgLatency, stats.MaxLatency)
+}
+
+func TestWriteSchedulerClosed(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newFakeClient(t, StreamDeckMini)
+	require.NoError(t, client.ClearKey(0))
+	require.NoError(t, client.Close())
+
+	assert.ErrorIs(t, client.ClearKey(0), ErrClosed)
+}
@@ -3,123 +3,519 @@
 package streamdeck
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
+	"image/draw"
+	"sync"
+	"time"
 
+	"github.com/disintegration/imaging"
 	hid "github.com/sstallion/go-hid"
 )
 
 // VendorElgato is the commonly used vendor ID by Elgato
 const VendorElgato = 0x0fd9
 
+// readTimeout defines how long a read waits for input reports before
+// checking whether the Client was closed
+const readTimeout = 100 * time.Millisecond
+
 // Collection of supported StreamDecks
 const (
+	// StreamDeck Original (0fd9:0060) 15 keys
+	StreamDeckOriginal uint16 = 0x0060
 	// Streamdeck Original V2 (0fd9:006d) 15 keys
 	StreamDeckOriginalV2 uint16 = 0x006d
+	// StreamDeck MK.2 (0fd9:0080) 15 keys
+	StreamDeckMK2 uint16 = 0x0080
 	// Stremdeck XL (0fd9:006c) 32 keys
 	StreamDeckXL uint16 = 0x006c
+	// StreamDeck XL V2 (0fd9:008f) 32 keys
+	StreamDeckXLV2 uint16 = 0x008f
 	// StreamDeck Mini (0fd9:0063) 6 keys
 	StreamDeckMini uint16 = 0x0063
 	// StreamDeck Mini V2 (0fd9:0090) 6 keys
 	StreamDeckMiniV2 uint16 = 0x0090
+	// StreamDeck Plus (0fd9:0084) 8 keys, 4 dials, touch strip
+	StreamDeckPlus uint16 = 0x0084
+	// StreamDeck Pedal (0fd9:0086) 3 keys, no display
+	StreamDeckPedal uint16 = 0x0086
+	// StreamDeck Neo (0fd9:009a) 8 keys, 2 touch keys, info bar
+	StreamDeckNeo uint16 = 0x009a
 )
 
-// Collection of supported EventType from keys
+// Collection of supported EventType from keys, dials and touch strips
 const (
 	EventTypeUp EventType = iota
 	EventTypeDown
+	EventTypeDialRotate
+	EventTypeDialDown
+	EventTypeDialUp
+	EventTypeTouchTap
+	EventTypeTouchLongPress
+	EventTypeTouchSwipe
+
+	// Gesture events emitted by the GestureDetector
+	EventTypeTap
+	EventTypeLongPress
+	EventTypeDoubleTap
+	EventTypeHoldRepeat
 )
 
+// ErrClosed is returned when writing to a key of a Client which was
+// closed or lost its device
+var ErrClosed = errors.New("client closed")
+
+// ErrNotSupported is returned when calling a function the device
+// does not have the hardware for
+var ErrNotSupported = errors.New("not supported by device")
+
 type (
 	// Client manages the connection to the StreamDeck
 	Client struct {
-		cfg       deckConfig
-		dev       *hid.Device
-		devType   uint16
-		keyStates []EventType
+		cfg           deckConfig
+		dev           Transport
+		devType       uint16
+		keyStates     []EventType
+		keyStatesLock *sync.RWMutex
+
+		// keyDownAt and dialDownAt are only accessed by the reader
+		keyDownAt  []time.Time
+		dialDownAt map[int]time.Time
+
+		frames *frameCache
+		life   *lifecycle
+		writes *writeScheduler
+	}
 
-		evts chan Event
+	// lifecycle is shared between all copies of a Client and controls
+	// the reader goroutine and the subscriptions
+	lifecycle struct {
+		closeErr  error
+		closeOnce sync.Once
+		done      chan struct{}
+		readErr   error
+		stop      chan struct{}
+		subs      *subscriptions
 	}
 
-	// EventType represents the state of a button (Up / Down)
+	// EventType represents the state of a button (Up / Down) or the
+	// kind of interaction with a dial or touch strip
 	EventType uint8
 
-	// Event represents a state change on a button
+	// Event represents a state change on a button, dial or touch strip
 	Event struct {
 		Key  int
 		Type EventType
+
+		// Dial contains the index of the dial for dial events
+		Dial int
+		// Delta contains the number of ticks the dial was rotated,
+		// negative values for counter-clockwise rotation
+		Delta int
+
+		// Point contains the touched position on the touch strip
+		Point image.Point
+		// End contains the position a swipe ended on the touch strip
+		End image.Point
+
+		// Time contains the time the input report was read from the
+		// device (or the gesture was detected)
+		Time time.Time
+		// Duration contains how long the key or dial was held down for
+		// up events and gestures
+		Duration time.Duration
 	}
 )
 
 // DeckToName contains a listing of device market-names for Streamdecks
 var DeckToName = map[uint16]string{
+	StreamDeckOriginal:   "StreamDeck Original",
 	StreamDeckOriginalV2: "StreamDeck Original V2",
+	StreamDeckMK2:        "StreamDeck MK.2",
 	StreamDeckXL:         "StreamDeck XL",
+	StreamDeckXLV2:       "StreamDeck XL V2",
 	StreamDeckMini:       "StreamDeck Mini",
 	StreamDeckMiniV2:     "StreamDeck Mini V2",
+	StreamDeckPlus:       "StreamDeck Plus",
+	StreamDeckPedal:      "StreamDeck Pedal",
+	StreamDeckNeo:        "StreamDeck Neo",
 }
 
 var decks = map[uint16]deckConfigCreateFunc{
+	StreamDeckOriginal:   newDeckConfigOriginal,
 	StreamDeckOriginalV2: newDeckConfigOriginalV2,
+	StreamDeckMK2:        newDeckConfigOriginalV2,
 	StreamDeckXL:         newDeckConfigXL,
+	StreamDeckXLV2:       newDeckConfigXL,
 	StreamDeckMini:       newDeckConfigMini,
 	StreamDeckMiniV2:     newDeckConfigMini,
+	StreamDeckPlus:       newDeckConfigPlus,
+	StreamDeckPedal:      newDeckConfigPedal,
+	StreamDeckNeo:        newDeckConfigNeo,
 }
 
 // New creates a new Client for the given device (see constants for supported types)
 func New(devicePID uint16) (*Client, error) {
+	if _, ok := decks[devicePID]; !ok {
+		return nil, fmt.Errorf("unsupported device 0x%04x", devicePID)
+	}
+
 	dev, err := hid.OpenFirst(VendorElgato, devicePID)
 	if err != nil {
 		return nil, fmt.Errorf("opening device: %w", err)
 	}
 
-	cfg := decks[devicePID]()
+	return NewWithTransport(devicePID, dev)
+}
+
+// NewWithTransport creates a new Client for the given device type
+// communicating through the given Transport instead of opening the
+// HID device itself
+func NewWithTransport(devicePID uint16, dev Transport) (*Client, error) {
+	createCfg, ok := decks[devicePID]
+	if !ok {
+		return nil, fmt.Errorf("unsupported device 0x%04x", devicePID)
+	}
+
+	cfg := createCfg()
 	cfg.SetDevice(dev)
 
 	client := &Client{
-		cfg:       cfg,
-		dev:       dev,
-		devType:   devicePID,
-		keyStates: make([]EventType, cfg.NumKeys()),
-
-		evts: make(chan Event, 100),
+		cfg:           cfg,
+		dev:           dev,
+		devType:       devicePID,
+		keyStates:     make([]EventType, cfg.NumKeys()+numTouchKeys(cfg)),
+		keyStatesLock: new(sync.RWMutex),
+
+		keyDownAt:  make([]time.Time, cfg.NumKeys()+numTouchKeys(cfg)),
+		dialDownAt: make(map[int]time.Time),
+
+		frames: newFrameCache(),
+		life: &lifecycle{
+			done: make(chan struct{}),
+			stop: make(chan struct{}),
+			subs: newSubscriptions(),
+		},
+		writes: newWriteScheduler(),
 	}
 
 	go client.read()
+	go client.writes.run(client.life.done)
 
 	return client, nil
 }
 
-// ClearAllKeys fills all keys with solid black
-func (c Client) ClearAllKeys() error { return c.cfg.ClearAllKeys() } //nolint:wrapcheck // wraps internal interface
+// ClearAllKeys fills all keys with solid black and switches off the
+// LEDs of touch keys
+func (c Client) ClearAllKeys() error {
+	if !c.HasDisplay() {
+		return c.cfg.ClearAllKeys() //nolint:wrapcheck // wraps internal interface
+	}
+
+	for i := 0; i < c.NumKeys()+c.NumTouchKeys(); i++ {
+		if err := c.ClearKey(i); err != nil {
+			return fmt.Errorf("clearing key %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// ClearKey fills a key with solid black, for touch keys the LED is
+// switched off
+func (c Client) ClearKey(keyIdx int) error {
+	return c.FillColor(keyIdx, color.RGBA{0x0, 0x0, 0x0, 0xff})
+}
+
+// Close stops listening for events, closes all subscriptions and
+// closes the underlying HID connection. Calling Close multiple times
+// is safe.
+func (c Client) Close() error {
+	c.life.closeOnce.Do(func() {
+		close(c.life.stop)
+		<-c.life.done
+		<-c.writes.stopped
+		c.life.closeErr = c.dev.Close()
+	})
+
+	return c.life.closeErr //nolint:wrapcheck // wraps internal interface
+}
+
+// Done returns a channel closed when the Client was closed or the
+// device was lost (for example by unplugging it) and no further events
+// will be received. Err then contains the reason of the loss.
+func (c Client) Done() <-chan struct{} { return c.life.done }
+
+// Encode converts a key image into the format sent to the device.
+// Encoded images are cached for all clients of the same device model,
+// so later calls of FillImage with the same image skip the encoding.
+// The result can be written to any key using FillEncoded.
+func (c Client) Encode(img image.Image) (EncodedImage, error) {
+	if !c.HasDisplay() {
+		return EncodedImage{}, ErrNotSupported
+	}
+
+	return c.encode(img, hashImage(img))
+}
+
+// EncodeCacheStats returns the usage of the cache of encoded key
+// images shared by all clients (see SetEncodeCacheSize)
+func (Client) EncodeCacheStats() EncodeCacheStats { return encodedPayloads.stats() }
+
+// Err returns the error which caused the loss of the device or nil
+// while the device is still available or was closed using Close
+func (c Client) Err() error {
+	select {
+	case <-c.life.done:
+		return c.life.readErr
+	default:
+		return nil
+	}
+}
+
+// FillColor fills a key with a solid color, touch keys (indices
+// following the regular keys) get their LED set to the color. Writing
+// the color the key already shows is skipped (see FrameStats).
+func (c Client) FillColor(keyIdx int, col color.RGBA) error {
+	if c.isTouchKey(keyIdx) {
+		return c.SetTouchKeyColor(keyIdx-c.NumKeys(), col)
+	}
+
+	if !c.HasDisplay() {
+		return c.cfg.FillColor(keyIdx, col) //nolint:wrapcheck // wraps internal interface
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, c.IconSize(), c.IconSize()))
+	draw.Draw(img, img.Bounds(), image.NewUniform(col), image.Point{}, draw.Src)
+
+	return c.FillImage(keyIdx, img)
+}
 
-// ClearKey fills a key with solid black
-func (c Client) ClearKey(keyIdx int) error { return c.cfg.ClearKey(keyIdx) } //nolint:wrapcheck // wraps internal interface
+// FillImage fills a key with an image, touch keys (indices following
+// the regular keys) get their LED set to the average color of the
+// image. Writing the image the key already shows is skipped (see
+// FrameStats).
+//
+// Writes are queued and FillImage returns after the image was written
+// or was replaced by a newer image for the same key. Keys recently
+// pressed or released are written first (see WriteQueueStats).
+func (c Client) FillImage(keyIdx int, img image.Image) error {
+	if c.isTouchKey(keyIdx) {
+		return c.SetTouchKeyColor(keyIdx-c.NumKeys(), averageColor(img))
+	}
 
-// Close closes the underlying HID connection
-func (c Client) Close() error { return c.dev.Close() } //nolint:wrapcheck // wraps internal interface
+	hash := hashImage(img)
+	return c.writes.submit(keyIdx, func() error {
+		return c.frames.write(keyIdx, hash, func() error {
+			enc, err := c.encode(img, hash)
+			if err != nil {
+				return err
+			}
 
-// FillColor fills a key with a solid color
-func (c Client) FillColor(keyIdx int, col color.RGBA) error { return c.cfg.FillColor(keyIdx, col) } //nolint:wrapcheck // wraps internal interface
+			return c.cfg.WriteEncoded(keyIdx, enc.payload) //nolint:wrapcheck // wraps internal interface
+		})
+	})
+}
 
-// FillImage fills a key with an image
-func (c Client) FillImage(keyIdx int, img image.Image) error { return c.cfg.FillImage(keyIdx, img) } //nolint:wrapcheck // wraps internal interface
+// FillEncoded fills a key with an image encoded by Encode skipping the
+// conversion of the image. The image must be encoded for the same
+// device model, touch keys are not supported.
+func (c Client) FillEncoded(keyIdx int, enc EncodedImage) error {
+	if c.isTouchKey(keyIdx) || !c.HasDisplay() {
+		return ErrNotSupported
+	}
+
+	if enc.model != c.cfg.Model() {
+		return fmt.Errorf("image encoded for model 0x%04x", enc.model)
+	}
+
+	return c.writes.submit(keyIdx, func() error {
+		return c.frames.write(keyIdx, enc.hash, func() error {
+			return c.cfg.WriteEncoded(keyIdx, enc.payload) //nolint:wrapcheck // wraps internal interface
+		})
+	})
+}
+
+// FillInfoBar fills the info bar with an image, the image is scaled
+// to fit the info bar if required
+func (c Client) FillInfoBar(img image.Image) error {
+	bar, ok := c.cfg.(infoBarDeckConfig)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	return bar.FillInfoBar(img) //nolint:wrapcheck // wraps internal interface
+}
+
+// FillLCD fills the whole LCD strip with an image
+func (c Client) FillLCD(img image.Image) error {
+	return c.FillLCDRegion(image.Rectangle{Max: c.LCDSize()}, img)
+}
+
+// FillLCDRegion fills a region of the LCD strip with an image, the
+// image is scaled to fit the region if required
+func (c Client) FillLCDRegion(rect image.Rectangle, img image.Image) error {
+	lcd, ok := c.cfg.(lcdDeckConfig)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	return lcd.FillLCDRegion(rect, img) //nolint:wrapcheck // wraps internal interface
+}
 
 // FillPanel slices a big image and fills the keys with the parts
-func (c Client) FillPanel(img image.RGBA) error { return c.cfg.FillPanel(img) } //nolint:wrapcheck // wraps internal interface
+func (c Client) FillPanel(img image.RGBA) error {
+	defer c.frames.invalidate()
+	return c.cfg.FillPanel(img) //nolint:wrapcheck // wraps internal interface
+}
+
+// FillPanelImage fills every key with its part of the image (see
+// SplitPanel)
+func (c Client) FillPanelImage(img image.Image, gap int) error {
+	if !c.HasDisplay() {
+		return ErrNotSupported
+	}
+
+	for k, tile := range c.SplitPanel(img, gap) {
+		if err := c.FillImage(k, tile); err != nil {
+			return fmt.Errorf("setting key image: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// FrameStats returns the number of key images sent to the device and
+// the number of writes skipped as the key already showed the content
+func (c Client) FrameStats() FrameStats { return c.frames.stats() }
 
 // GetFimwareVersion retrieves the firmware version
 func (c Client) GetFimwareVersion() (string, error) { return c.cfg.GetFimwareVersion() } //nolint:wrapcheck // wraps internal interface
 
-// IconSize returns the required icon size for the StreamDeck
+// HasDisplay reports whether the keys of the StreamDeck are able to
+// display images
+func (c Client) HasDisplay() bool { return c.cfg.IconSize() > 0 }
+
+// HasInfoBar reports whether the StreamDeck has an info bar display
+func (c Client) HasInfoBar() bool {
+	_, ok := c.cfg.(infoBarDeckConfig)
+	return ok
+}
+
+// HeldKeys returns the indices of all keys (including touch keys)
+// currently held down in ascending order
+func (c Client) HeldKeys() []int {
+	c.keyStatesLock.RLock()
+	defer c.keyStatesLock.RUnlock()
+
+	var held []int
+	for k, state := range c.keyStates {
+		if state == EventTypeDown {
+			held = append(held, k)
+		}
+	}
+
+	return held
+}
+
+// IconSize returns the required icon size for the StreamDeck or zero
+// if the device has no display
 func (c Client) IconSize() int { return c.cfg.IconSize() }
 
+// InfoBarSize returns the size of the info bar or a zero size if the
+// device has no info bar
+func (c Client) InfoBarSize() image.Point {
+	if bar, ok := c.cfg.(infoBarDeckConfig); ok {
+		return bar.InfoBarSize()
+	}
+
+	return image.Point{}
+}
+
+// DialRegion returns the region of the LCD strip above the given dial
+// or an empty rectangle if the device has no dials or LCD strip
+func (c Client) DialRegion(dial int) image.Rectangle {
+	size := c.LCDSize()
+	if c.NumDials() == 0 || size.X == 0 || dial < 0 || dial >= c.NumDials() {
+		return image.Rectangle{}
+	}
+
+	width := size.X / c.NumDials()
+	return image.Rect(dial*width, 0, (dial+1)*width, size.Y)
+}
+
+// KeyColumns returns the number of keys in each row of the StreamDeck
+func (c Client) KeyColumns() int { return c.cfg.KeyColumns() }
+
+// KeyPanelRect returns the part of an image of PanelSize shown on the
+// given key
+func (c Client) KeyPanelRect(keyIdx, gap int) image.Rectangle {
+	var (
+		step = c.IconSize() + gap
+		kx   = keyIdx % c.cfg.KeyColumns()
+		ky   = keyIdx / c.cfg.KeyColumns()
+	)
+
+	return image.Rect(0, 0, c.IconSize(), c.IconSize()).Add(image.Pt(kx*step, ky*step))
+}
+
+// KeyRows returns the number of rows of keys of the StreamDeck
+func (c Client) KeyRows() int { return c.cfg.KeyRows() }
+
+// LCDSize returns the size of the LCD strip or a zero size if the
+// device has no LCD strip
+func (c Client) LCDSize() image.Point {
+	if lcd, ok := c.cfg.(lcdDeckConfig); ok {
+		return lcd.LCDSize()
+	}
+
+	return image.Point{}
+}
+
+// Model returns the product ID of the StreamDeck (see constants for
+// supported types)
+func (c Client) Model() uint16 { return c.devType }
+
+// NumDials returns the number of dials available on the StreamDeck
+func (c Client) NumDials() int {
+	if dials, ok := c.cfg.(dialDeckConfig); ok {
+		return dials.NumDials()
+	}
+
+	return 0
+}
+
 // NumKeys returns the number of keys available on the StreamDeck
 func (c Client) NumKeys() int { return c.cfg.NumKeys() }
 
+// NumTouchKeys returns the number of touch keys available on the
+// StreamDeck. Touch keys are addressed by the key indices following
+// the regular keys.
+func (c Client) NumTouchKeys() int { return numTouchKeys(c.cfg) }
+
+// PanelSize returns the size of an image spanning all keys including
+// the given number of pixels hidden by the bezel between two keys
+func (c Client) PanelSize(gap int) image.Point {
+	return image.Pt(
+		c.cfg.KeyColumns()*c.IconSize()+(c.cfg.KeyColumns()-1)*gap,
+		c.cfg.KeyRows()*c.IconSize()+(c.cfg.KeyRows()-1)*gap,
+	)
+}
+
+// InvalidateFrameCache forgets the content of all keys so the next
+// write to every key is sent to the device. This is required when the
+// content of the keys was changed without using this Client.
+func (c Client) InvalidateFrameCache() { c.frames.invalidate() }
+
 // ResetToLogo restores the original Elgato StreamDeck logo
-func (c Client) ResetToLogo() error { return c.cfg.ResetToLogo() } //nolint:wrapcheck // wraps internal interface
+func (c Client) ResetToLogo() error {
+	defer c.frames.invalidate()
+	return c.cfg.ResetToLogo() //nolint:wrapcheck // wraps internal interface
+}
 
 // Serial returns the device serial
 func (c Client) Serial() (string, error) { return c.dev.GetSerialNbr() } //nolint:wrapcheck // wraps internal interface
@@ -127,25 +523,203 @@ func (c Client) Serial() (string, error) { return c.dev.GetSerialNbr() } //nolin
 // SetBrightness sets the brightness of the keys (0-100)
 func (c Client) SetBrightness(pct int) error { return c.cfg.SetBrightness(pct) } //nolint:wrapcheck // wraps internal interface
 
-// Subscribe returns a channel to listen for incoming events
-func (c Client) Subscribe() <-chan Event { return c.evts }
+// SetTouchKeyColor sets the LED color of the given touch key (0-based,
+// not including the regular keys)
+func (c Client) SetTouchKeyColor(touchKeyIdx int, col color.RGBA) error {
+	tk, ok := c.cfg.(touchKeyDeckConfig)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	keyIdx := c.NumKeys() + touchKeyIdx
+	return c.writes.submit(keyIdx, func() error {
+		return c.frames.write(keyIdx, hashColor(col), func() error {
+			return tk.SetTouchKeyColor(touchKeyIdx, col) //nolint:wrapcheck // wraps internal interface
+		})
+	})
+}
+
+// SplitPanel scales an image to cover all keys (see PanelSize), crops
+// the overflow and returns the part of the image for every key. The
+// gap is the number of pixels hidden by the bezel between two
+// neighbouring keys.
+func (c Client) SplitPanel(img image.Image, gap int) []image.Image {
+	size := c.PanelSize(gap)
+	panel := imaging.Fill(img, size.X, size.Y, imaging.Center, imaging.Lanczos)
+
+	tiles := make([]image.Image, c.NumKeys())
+	for k := range tiles {
+		tiles[k] = panel.SubImage(c.KeyPanelRect(k, gap))
+	}
+
+	return tiles
+}
+
+// Subscribe returns a channel to listen for incoming events. Every
+// subscriber gets its own buffer and policy how to handle a full
+// buffer (see WithBuffer and WithPolicy). The channel is closed when
+// the context is cancelled, the Client is closed or the device is
+// lost (see Done and Err).
+func (c Client) Subscribe(ctx context.Context, opts ...SubscribeOption) <-chan Event {
+	sub := &subscription{ctx: ctx, buffer: defaultSubscriptionBuffer}
+	for _, opt := range opts {
+		opt(sub)
+	}
+	sub.ch = make(chan Event, sub.buffer)
+
+	if !c.life.subs.add(sub) {
+		// Client is already closed
+		close(sub.ch)
+		return sub.ch
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.life.subs.remove(sub)
+		case <-c.life.done:
+		}
+	}()
+
+	return sub.ch
+}
+
+// WriteQueueStats returns metrics of the queue used to write key
+// images to the device
+func (c Client) WriteQueueStats() WriteQueueStats { return c.writes.stats() }
+
+func (c Client) emit(evt Event) { c.life.subs.emit(evt, c.life.stop) }
+
+// encode returns the cached payload for the image or encodes it
+func (c Client) encode(img image.Image, hash uint64) (EncodedImage, error) {
+	enc := EncodedImage{hash: hash, model: c.cfg.Model()}
+
+	if payload, ok := encodedPayloads.get(payloadKey{hash: hash, model: enc.model}); ok {
+		enc.payload = payload
+		return enc, nil
+	}
+
+	payload, err := c.cfg.EncodeImage(img)
+	if err != nil {
+		return EncodedImage{}, fmt.Errorf("encoding image: %w", err)
+	}
+
+	enc.payload = payload
+	encodedPayloads.put(enc)
+
+	return enc, nil
+}
 
-func (c Client) emit(key int, t EventType) { c.evts <- Event{Key: key, Type: t} }
+func (c Client) isTouchKey(keyIdx int) bool {
+	return keyIdx >= c.NumKeys() && keyIdx < c.NumKeys()+c.NumTouchKeys()
+}
 
 func (c *Client) read() {
+	decoder, hasDecoder := c.cfg.(inputReportDecoder)
+
+	defer func() {
+		c.life.subs.closeAll()
+		close(c.life.done)
+	}()
+
 	for {
+		select {
+		case <-c.life.stop:
+			return
+		default:
+		}
+
 		buf := make([]byte, 1024)
-		_, err := c.dev.Read(buf)
-		if err != nil {
+		_, err := c.dev.ReadWithTimeout(buf, readTimeout)
+		now := time.Now()
+		switch {
+		case errors.Is(err, hid.ErrTimeout):
+			// No data available, check for Close and read again
 			continue
+
+		case err != nil:
+			// Read errors are not recoverable: the device is gone
+			c.life.readErr = fmt.Errorf("reading from device: %w", err)
+			return
+		}
+
+		if hasDecoder {
+			evts, isKeyReport := decoder.DecodeInputReport(buf)
+			for _, evt := range evts {
+				c.emit(c.stamp(evt, now))
+			}
+
+			if !isKeyReport {
+				continue
+			}
 		}
 
-		for k := 0; k < c.cfg.NumKeys(); k++ {
-			newState := EventType(buf[k+c.cfg.KeyDataOffset()])
+		for k := 0; k < len(c.keyStates); k++ {
+			newState := EventType(buf[c.cfg.TransformKeyIndex(k)+c.cfg.KeyDataOffset()])
 			if c.keyStates[k] != newState {
-				c.emit(k, newState)
+				c.keyStatesLock.Lock()
 				c.keyStates[k] = newState
+				c.keyStatesLock.Unlock()
+
+				c.emit(c.stamp(Event{Key: k, Type: newState}, now))
 			}
 		}
 	}
 }
+
+// stamp sets the read time on the event and calculates the hold
+// duration for up events, pressed keys get their writes prioritized
+func (c *Client) stamp(evt Event, now time.Time) Event {
+	evt.Time = now
+
+	switch evt.Type {
+	case EventTypeDown:
+		c.keyDownAt[evt.Key] = now
+		c.writes.interact(evt.Key, now)
+
+	case EventTypeUp:
+		c.writes.interact(evt.Key, now)
+		if down := c.keyDownAt[evt.Key]; !down.IsZero() {
+			evt.Duration = now.Sub(down)
+		}
+
+	case EventTypeDialDown:
+		c.dialDownAt[evt.Dial] = now
+
+	case EventTypeDialUp:
+		if down, ok := c.dialDownAt[evt.Dial]; ok {
+			evt.Duration = now.Sub(down)
+		}
+	}
+
+	return evt
+}
+
+func averageColor(img image.Image) color.RGBA {
+	var (
+		bounds     = img.Bounds()
+		r, g, b, n uint64
+	)
+
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			pr, pg, pb, _ := img.At(x, y).RGBA()
+			r, g, b = r+uint64(pr>>8), g+uint64(pg>>8), b+uint64(pb>>8)
+			n++
+		}
+	}
+
+	if n == 0 {
+		return color.RGBA{0x0, 0x0, 0x0, 0xff}
+	}
+
+	return color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), 0xff} //#nosec:G115 // averages of 8-bit values
+}
+
+func numTouchKeys(cfg deckConfig) int {
+	if tk, ok := cfg.(touchKeyDeckConfig); ok {
+		return tk.NumTouchKeys()
+	}
+
+	return 0
+}
@@ -0,0 +1,174 @@
+package streamdeck
+
+import (
+	"context"
+	"sync"
+)
+
+const defaultSubscriptionBuffer = 100
+
+// Collection of policies applied when the buffer of a subscription
+// is full
+const (
+	// SubscriptionBlock blocks the event delivery until the subscriber
+	// reads the event, its context is cancelled or the Client is closed.
+	// Slow subscribers using this policy delay all other subscribers.
+	SubscriptionBlock SubscriptionPolicy = iota
+	// SubscriptionDropNewest discards the event which does not fit
+	// into the buffer anymore
+	SubscriptionDropNewest
+	// SubscriptionDropOldest discards the oldest buffered event to make
+	// room for the new one
+	SubscriptionDropOldest
+)
+
+type (
+	// SubscriptionPolicy defines how events are handled when the
+	// buffer of a subscriber is full
+	SubscriptionPolicy uint8
+
+	// SubscribeOption configures a subscription
+	SubscribeOption func(*subscription)
+
+	subscription struct {
+		ctx    context.Context
+		buffer int
+		ch     chan Event
+		policy SubscriptionPolicy
+
+		lock   sync.Mutex
+		closed bool
+	}
+
+	subscriptions struct {
+		lock   sync.Mutex
+		closed bool
+		subs   map[*subscription]struct{}
+	}
+)
+
+// WithBuffer sets the number of events buffered for the subscriber
+// (default 100)
+func WithBuffer(n int) SubscribeOption {
+	return func(s *subscription) { s.buffer = max(n, 0) }
+}
+
+// WithPolicy sets the policy applied when the buffer of the subscriber
+// is full (default SubscriptionBlock)
+func WithPolicy(policy SubscriptionPolicy) SubscribeOption {
+	return func(s *subscription) { s.policy = policy }
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{subs: make(map[*subscription]struct{})}
+}
+
+// add registers a new subscription or returns false if the
+// subscriptions were already closed
+func (s *subscriptions) add(sub *subscription) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.subs[sub] = struct{}{}
+	return true
+}
+
+func (s *subscriptions) closeAll() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.closed = true
+	for sub := range s.subs {
+		sub.close()
+		delete(s.subs, sub)
+	}
+}
+
+func (s *subscriptions) emit(evt Event, stop <-chan struct{}) {
+	for _, sub := range s.list() {
+		sub.send(evt, stop)
+	}
+}
+
+func (s *subscriptions) list() []*subscription {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	out := make([]*subscription, 0, len(s.subs))
+	for sub := range s.subs {
+		out = append(out, sub)
+	}
+
+	return out
+}
+
+func (s *subscriptions) remove(sub *subscription) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	sub.close()
+	delete(s.subs, sub)
+}
+
+func (s *subscription) close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	s.closed = true
+	close(s.ch)
+}
+
+func (s *subscription) send(evt Event, stop <-chan struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	switch s.policy {
+	case SubscriptionDropNewest:
+		select {
+		case s.ch <- evt:
+		default:
+		}
+
+	case SubscriptionDropOldest:
+		if cap(s.ch) == 0 {
+			// Nothing buffered to be dropped
+			select {
+			case s.ch <- evt:
+			default:
+			}
+			return
+		}
+
+		for {
+			select {
+			case s.ch <- evt:
+				return
+			default:
+			}
+
+			select {
+			case <-s.ch:
+			default:
+			}
+		}
+
+	default:
+		select {
+		case s.ch <- evt:
+		case <-s.ctx.Done():
+		case <-stop:
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package streamdeck
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collectEvents(t *testing.T, evts <-chan Event, n int) []Event {
+	t.Helper()
+
+	var out []Event
+	for range n {
+		select {
+		case evt := <-evts:
+			out = append(out, evt)
+		case <-time.After(testEventTimeout):
+			t.Fatal("timeout waiting for event")
+		}
+	}
+
+	return out
+}
+
+func waitClosed(t *testing.T, evts <-chan Event) {
+	t.Helper()
+
+	timeout := time.After(testEventTimeout)
+	for {
+		select {
+		case _, ok := <-evts:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("timeout waiting for channel to close")
+		}
+	}
+}
+
+func TestSubscribeMultiple(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newFakeClient(t, StreamDeckMini)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	first := client.Subscribe(ctx)
+	second := client.Subscribe(t.Context())
+
+	require.NoError(t, fake.Press(0))
+
+	expected := []Event{{Key: 0, Type: EventTypeDown}}
+	assert.Equal(t, expected, withoutTiming(collectEvents(t, first, 1)...))
+	assert.Equal(t, expected, withoutTiming(collectEvents(t, second, 1)...))
+
+	// Cancelled subscriptions are closed without affecting others
+	cancel()
+	waitClosed(t, first)
+
+	require.NoError(t, fake.Release(0))
+	assert.Equal(t, []Event{{Key: 0, Type: EventTypeUp}}, withoutTiming(collectEvents(t, second, 1)...))
+}
+
+func TestSubscribeDropPolicies(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newFakeClient(t, StreamDeckMini)
+
+	newest := client.Subscribe(t.Context(), WithBuffer(1), WithPolicy(SubscriptionDropNewest))
+	oldest := client.Subscribe(t.Context(), WithBuffer(1), WithPolicy(SubscriptionDropOldest))
+	all := client.Subscribe(t.Context(), WithBuffer(4))
+
+	require.NoError(t, fake.Press(1))
+	require.NoError(t, fake.Press(2))
+	require.NoError(t, fake.Press(3))
+	require.NoError(t, fake.Release(3))
+
+	// Wait for all events to be read and delivered to all subscribers
+	collectEvents(t, all, 4)
+	require.NoError(t, client.Close())
+
+	assert.Equal(t, []Event{{Key: 1, Type: EventTypeDown}}, withoutTiming(collectEvents(t, newest, 1)...))
+	assert.Equal(t, []Event{{Key: 3, Type: EventTypeUp}}, withoutTiming(collectEvents(t, oldest, 1)...))
+}
+
+func TestCloseStopsReader(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newFakeClient(t, StreamDeckXL)
+
+	// Blocking subscriber which is never read must not prevent Close
+	blocked := client.Subscribe(t.Context(), WithBuffer(0))
+	evts := client.Subscribe(t.Context())
+
+	require.NoError(t, fake.Press(4))
+
+	require.NoError(t, client.Close())
+	require.NoError(t, client.Close())
+
+	select {
+	case <-client.Done():
+	default:
+		t.Fatal("client not done after Close")
+	}
+
+	require.NoError(t, client.Err())
+	waitClosed(t, blocked)
+	waitClosed(t, evts)
+
+	// Subscribing to a closed client yields a closed channel
+	waitClosed(t, client.Subscribe(t.Context()))
+}
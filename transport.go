@@ -0,0 +1,27 @@
+package streamdeck
+
+import (
+	"time"
+
+	hid "github.com/sstallion/go-hid"
+)
+
+type (
+	// Transport represents the connection to a StreamDeck. It is
+	// satisfied by *hid.Device for real hardware and by FakeDeck for
+	// tests without a physical device. ReadWithTimeout must return
+	// hid.ErrTimeout when no report was available within the timeout.
+	Transport interface {
+		Write(p []byte) (int, error)
+		ReadWithTimeout(p []byte, timeout time.Duration) (int, error)
+
+		GetFeatureReport(p []byte) (int, error)
+		SendFeatureReport(p []byte) (int, error)
+
+		GetSerialNbr() (string, error)
+
+		Close() error
+	}
+)
+
+var _ Transport = (*hid.Device)(nil)